@@ -2,8 +2,11 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"database/sql"
 	"encoding/json"
-	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"math"
 	"os"
@@ -13,23 +16,27 @@ import (
 
 	"github.com/frachea/macro-tracker/config"
 	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/export"
 	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/frachea/macro-tracker/internal/foodref"
+	"github.com/frachea/macro-tracker/internal/importer"
+	"github.com/frachea/macro-tracker/internal/planner"
+	"github.com/frachea/macro-tracker/internal/report"
+	"github.com/frachea/macro-tracker/internal/shopping"
 )
 
-// Structure pour stocker les objectifs de macronutriments
-type MacroTargets struct {
-	Calories float64 `json:"calories"`
-	Proteins float64 `json:"proteins"`
-	Carbs    float64 `json:"carbs"`
-	Fats     float64 `json:"fats"`
-	Fiber    float64 `json:"fiber"`
-}
-
 var (
 	currentUser *database.User
 	db          *database.DB
 )
 
+var validMealTypes = map[string]bool{
+	"petit-dejeuner": true,
+	"dejeuner":       true,
+	"diner":          true,
+	"collation":      true,
+}
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
@@ -51,22 +58,40 @@ func main() {
 		os.Exit(1)
 	}
 
-	fdcClient := fdc.NewClient(cfg.FDCApiKey)
+	var fdcClient fdc.Provider = fdc.NewClientWithOptions(cfg.FDCApiKey, fdc.Options{
+		BaseURL:   cfg.FDCBaseURL,
+		Timeout:   cfg.FDCTimeout,
+		Cache:     db,
+		SearchTTL: cfg.FDCSearchTTL,
+	})
+	if cfg.FDCProvider == "local" {
+		// Aliments importés des fichiers FDC avec la commande fdcimport
+		fdcClient = database.NewLocalProvider(db)
+	}
 
 	fmt.Println("Bienvenue dans Macro-Tracker!")
 
 	currentUser = setupUser()
 
 	fmt.Println("\nCommandes disponibles:")
-	fmt.Println("- search <nom de l'aliment>: rechercher un aliment")
-	fmt.Println("- add <fdcId> <quantité> <type de repas>: ajouter un aliment consommé")
+	fmt.Println("- search <nom de l'aliment> [--page N] [--type types] [--brand fabricant] [--sort critère]: rechercher un aliment")
+	fmt.Println("- scan <code-barres>: rechercher un produit de marque par son code-barres")
+	fmt.Println("- add <fdcId|perso:id|recette:id> <quantité> [unité] <type de repas>: ajouter un aliment ou une recette consommé")
+	fmt.Println("- edit <id>: modifier un aliment consommé")
+	fmt.Println("- delete <id>: supprimer un aliment consommé")
 	fmt.Println("- report: voir le bilan nutritionnel du jour")
 	fmt.Println("- plan: gérer les journées types")
+	fmt.Println("- recipe: gérer vos recettes")
+	fmt.Println("- food: gérer vos aliments personnalisés")
+	fmt.Println("- shopping <id>[:jours] ... [--format text|md|json]: liste de courses des journées types")
+	fmt.Println("- cache <stats|clear [fdcId]>: consulter ou vider le cache des aliments FDC")
 	fmt.Println("- health: afficher les informations de santé (IMC, masse grasse)")
 	fmt.Println("- goals: définir ou consulter vos objectifs nutritionnels")
 	fmt.Println("- history [jours]: afficher l'historique (défaut: 7 jours)")
+	fmt.Println("- summary <week|month>: bilan de la semaine ou du mois en cours")
 	fmt.Println("- profile: modifier vos informations personnelles")
-	fmt.Println("- export: exporter vos données en CSV")
+	fmt.Println("- export [--from AAAA-MM-JJ] [--to AAAA-MM-JJ] [--format csv|json|md] [--out fichier]: exporter vos données")
+	fmt.Println("- import <fichier.csv> [--source csv|mfp|cronometer] [--dry-run]: importer des repas")
 	fmt.Println("- exit: quitter l'application")
 
 	scanner := bufio.NewReader(os.Stdin)
@@ -83,27 +108,49 @@ func main() {
 		command := args[0]
 		switch command {
 		case "search":
+			handleSearch(scanner, fdcClient, args[1:])
+
+		case "scan":
 			if len(args) < 2 {
-				fmt.Println("Usage: search <nom de l'aliment>")
+				fmt.Println("Usage: scan <code-barres>")
 				continue
 			}
-			query := strings.Join(args[1:], " ")
-			handleSearch(fdcClient, query)
+			handleScan(fdcClient, args[1])
 
 		case "add":
 			if len(args) < 4 {
-				fmt.Println("Usage: add <fdcId> <quantité en grammes> <type de repas>")
+				fmt.Println("Usage: add <fdcId|perso:id|recette:id> <quantité> [unité] <type de repas>")
 				fmt.Println("Types de repas disponibles: petit-dejeuner, dejeuner, diner, collation")
 				continue
 			}
 			handleAdd(fdcClient, args[1:])
 
+		case "edit":
+			if len(args) < 2 {
+				fmt.Println("Usage: edit <id>")
+				continue
+			}
+			handleEdit(scanner, args[1])
+
+		case "delete":
+			if len(args) < 2 {
+				fmt.Println("Usage: delete <id>")
+				continue
+			}
+			handleDelete(args[1])
+
 		case "report":
 			handleReport()
 
 		case "plan":
 			handlePlanCommand(scanner, db, fdcClient, currentUser)
 
+		case "recipe":
+			handleRecipeCommand(scanner, fdcClient)
+
+		case "food":
+			handleFoodCommand(scanner)
+
 		case "health":
 			handleHealth()
 
@@ -124,18 +171,35 @@ func main() {
 			}
 			handleHistory(days)
 
+		case "summary":
+			if len(args) < 2 {
+				fmt.Println("Usage: summary <week|month>")
+				continue
+			}
+			handleSummary(report.Period(args[1]))
+
 		case "profile":
 			handleProfile(scanner)
 
 		case "export":
-			handleExport()
+			handleExport(args[1:])
+
+		case "import":
+			handleImport(args[1:])
+
+		case "shopping":
+			handleShopping(fdcClient, args[1:])
+
+		case "cache":
+			handleCache(fdcClient, args[1:])
 
 		case "exit":
+			db.Close()
 			fmt.Println("Au revoir!")
 			return
 
 		default:
-			fmt.Println("Commande inconnue. Commandes disponibles: search, add, report, plan, health, goals, history, profile, export, exit")
+			fmt.Println("Commande inconnue. Commandes disponibles: search, scan, add, edit, delete, report, plan, recipe, food, shopping, cache, health, goals, history, summary, profile, export, import, exit")
 		}
 	}
 }
@@ -189,94 +253,229 @@ func setupUser() *database.User {
 	return user
 }
 
-func handleSearch(client *fdc.Client, query string) {
-	resp, err := client.SearchFoods(query)
-	if err != nil {
-		fmt.Printf("Erreur lors de la recherche: %v\n", err)
+// searchPageSize est le nombre d'aliments FDC affichés par page de
+// recherche
+const searchPageSize = 25
+
+const searchUsage = "Usage: search <nom de l'aliment> [--page N] [--size N] [--type Foundation,Branded...] [--brand fabricant] [--sort description|dataType|fdcId|publishedDate] [--desc]\n" +
+	"Les options peuvent être placées avant ou après le nom de l'aliment."
+
+func handleSearch(scanner *bufio.Reader, client fdc.Provider, args []string) {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	page := flags.Int("page", 1, "première page affichée")
+	size := flags.Int("size", searchPageSize, "nombre d'aliments FDC par page")
+	types := flags.String("type", "", "types de données séparés par des virgules (défaut: Foundation,SR Legacy)")
+	brand := flags.String("brand", "", "fabricant des produits de marque")
+	sortStr := flags.String("sort", "", "tri (description, dataType, fdcId, publishedDate ; défaut: pertinence)")
+	desc := flags.Bool("desc", false, "tri décroissant")
+
+	// Les options peuvent précéder, suivre ou séparer les mots recherchés
+	var words []string
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		words = append(words, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	query := strings.Join(words, " ")
+	if query == "" {
+		fmt.Println(searchUsage)
 		return
 	}
 
-	if len(resp.Foods) == 0 {
-		fmt.Println("Aucun aliment trouvé.")
+	opts := fdc.SearchOptions{PageNumber: *page, PageSize: *size, BrandOwner: *brand}
+	if *types != "" {
+		for _, dataType := range strings.Split(*types, ",") {
+			opts.DataTypes = append(opts.DataTypes, strings.TrimSpace(dataType))
+		}
+	}
+	if *sortStr != "" {
+		sortBy, err := fdc.ParseSort(*sortStr)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			return
+		}
+		opts.SortBy = sortBy
+	}
+	if *desc {
+		opts.SortOrder = "desc"
+	}
+	if err := opts.Validate(); err != nil {
+		fmt.Printf("Erreur: %v\n", err)
 		return
 	}
 
-	fmt.Println("\nRésultats de la recherche:")
-	for _, food := range resp.Foods {
-		fmt.Printf("- ID: %d, Nom: %s\n", food.FdcID, food.Description)
+	// Les aliments personnalisés, sans type FDC ni fabricant, sont affichés
+	// avant les résultats FDC d'une recherche sans filtre
+	var customFoods []database.CustomFood
+	if len(opts.DataTypes) == 0 && opts.BrandOwner == "" {
+		var err error
+		customFoods, err = db.SearchCustomFoods(currentUser.ID, query)
+		if err != nil {
+			fmt.Printf("Erreur lors de la recherche des aliments personnalisés: %v\n", err)
+		}
+	}
+	if len(customFoods) > 0 {
+		fmt.Println("\nVos aliments:")
+		for _, food := range customFoods {
+			ref := foodref.Ref{Source: foodref.Custom, ID: food.ID}
+			fmt.Printf("- ID: %s, Nom: %s\n", ref, customFoodLabel(&food))
+		}
+	}
+
+	for {
+		result, err := db.SearchFoods(context.Background(), client, currentUser.ID, query, opts)
+		if err != nil {
+			fmt.Printf("Erreur lors de la recherche: %v\n", err)
+			return
+		}
+		if result.TotalHits == 0 {
+			if len(customFoods) == 0 {
+				fmt.Println("Aucun aliment trouvé.")
+			}
+			return
+		}
+
+		fmt.Printf("\nRésultats de la recherche (page %d/%d, %d aliments FDC):\n", opts.Page(), max(result.TotalPages, 1), result.TotalHits)
+		for _, food := range result.Foods {
+			ref := foodref.Ref{Source: foodref.FDC, ID: food.FdcID}
+			fmt.Printf("- ID: %s, Nom: %s\n", ref, food.Description)
+		}
+		if result.TotalPages <= 1 {
+			return
+		}
+
+		fmt.Print("\n[s]uivante, [p]récédente, numéro de page ou Entrée pour terminer: ")
+		input, _ := scanner.ReadString('\n')
+		switch input = strings.TrimSpace(input); input {
+		case "":
+			return
+		case "s":
+			opts.PageNumber = min(opts.Page()+1, result.TotalPages)
+		case "p":
+			opts.PageNumber = max(opts.Page()-1, 1)
+		default:
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 || n > result.TotalPages {
+				fmt.Println("Page invalide.")
+				return
+			}
+			opts.PageNumber = n
+		}
 	}
 }
 
-func handleAdd(client *fdc.Client, args []string) {
-	fdcID, err := strconv.Atoi(args[0])
-	if err != nil {
-		fmt.Println("ID d'aliment invalide")
+// Recherche un produit de marque par son code-barres GTIN/UPC
+func handleScan(client fdc.Provider, code string) {
+	if !fdc.ValidBarcode(code) {
+		fmt.Println("Code-barres invalide (8 à 14 chiffres attendus)")
 		return
 	}
 
-	amount, err := strconv.ParseFloat(args[1], 64)
+	food, err := client.GetFoodByBarcodeContext(context.Background(), code)
 	if err != nil {
-		fmt.Println("Quantité invalide")
+		if errors.Is(err, fdc.ErrNotFound) {
+			fmt.Println("Aucun produit ne correspond à ce code-barres.")
+			return
+		}
+		fmt.Printf("Erreur lors de la recherche: %v\n", err)
 		return
 	}
 
-	mealType := args[2]
-	validMealTypes := map[string]bool{
-		"petit-dejeuner": true,
-		"dejeuner":       true,
-		"diner":          true,
-		"collation":      true,
+	ref := foodref.Ref{Source: foodref.FDC, ID: food.FdcID}
+	fmt.Printf("\n%s\n", food.Description)
+	if food.BrandOwner != "" {
+		fmt.Printf("- Marque: %s\n", food.BrandOwner)
 	}
+	fmt.Printf("- ID: %s\n", ref)
+
+	proteins, carbs, fats, calories, fiber := food.MacrosFor(100)
+	fmt.Println("- Pour 100g:")
+	fmt.Printf("  Calories: %.0f kcal\n", calories)
+	fmt.Printf("  Protéines: %.1fg\n", proteins)
+	fmt.Printf("  Glucides: %.1fg\n", carbs)
+	fmt.Printf("  Lipides: %.1fg\n", fats)
+	fmt.Printf("  Fibres: %.1fg\n", fiber)
+	fmt.Printf("\nPour l'ajouter: add %s <quantité> <type de repas>\n", ref)
+}
 
-	if !validMealTypes[mealType] {
-		fmt.Println("Type de repas invalide. Utilisez: petit-dejeuner, dejeuner, diner, ou collation")
+func handleAdd(client fdc.Provider, args []string) {
+	ref, err := foodref.Parse(args[0])
+	if err != nil {
+		fmt.Printf("Erreur: %v\n", err)
 		return
 	}
 
-	food, err := client.GetFood(fdcID)
+	// La quantité peut être suivie d'une unité (150g, 2 slice, 1.5 cup) ;
+	// grammes par défaut
+	amount, unit, err := fdc.ParseQuantity(args[1])
 	if err != nil {
-		fmt.Printf("Erreur lors de la récupération des détails de l'aliment: %v\n", err)
+		fmt.Println("Quantité invalide")
 		return
 	}
+	if len(args) > 3 {
+		if unit != "" {
+			fmt.Println("Usage: add <fdcId|perso:id|recette:id> <quantité> [unité] <type de repas>")
+			return
+		}
+		unit = strings.Join(args[2:len(args)-1], " ")
+	}
 
-	proteins, carbs, fats, calories, fiber := food.GetMacros()
-	
-	// S'assurer que les valeurs sont positives
-	if proteins < 0 {
-		proteins = 0
+	mealType := args[len(args)-1]
+	if !validMealTypes[mealType] {
+		fmt.Println("Type de repas invalide. Utilisez: petit-dejeuner, dejeuner, diner, ou collation")
+		return
 	}
-	if carbs < 0 {
-		carbs = 0
+
+	if ref.Source == foodref.Recipe {
+		handleAddRecipe(ref.ID, amount, unit, mealType)
+		return
 	}
-	if fats < 0 {
-		fats = 0
+
+	if ref.Source == foodref.Custom {
+		grams, ok := fdc.MassUnitGrams(unit)
+		if !ok {
+			fmt.Println("Unité invalide. Utilisez: g, kg, oz ou lb")
+			return
+		}
+		handleAddCustomFood(ref.ID, amount*grams, mealType)
+		return
 	}
-	if calories < 0 {
-		calories = 0
+
+	fdcID := ref.ID
+	food, err := client.GetFoodContext(context.Background(), fdcID)
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des détails de l'aliment: %v\n", err)
+		return
 	}
-	if fiber < 0 {
-		fiber = 0
+
+	// Convertir les unités ménagères (slice, cup...) en grammes
+	amount, err = food.GramsFor(amount, unit)
+	if err != nil {
+		fmt.Printf("Erreur: %v\n", err)
+		return
 	}
-	
+
 	// Calculer les valeurs en fonction de la quantité
-	mealProteins := proteins * amount / 100
-	mealCarbs := carbs * amount / 100
-	mealFats := fats * amount / 100
-	mealCalories := calories * amount / 100
-	mealFiber := fiber * amount / 100
+	mealProteins, mealCarbs, mealFats, mealCalories, mealFiber := food.MacrosFor(amount)
 
 	meal := &database.Meal{
-		UserID:   currentUser.ID,
-		MealType: mealType,
-		MealDate: time.Now(),
-		FoodID:   fdcID,
-		FoodName: food.Description,
-		Amount:   amount,
-		Proteins: mealProteins,
-		Carbs:    mealCarbs,
-		Fats:     mealFats,
-		Calories: mealCalories,
-		Fiber:    mealFiber,
+		UserID:         currentUser.ID,
+		MealType:       mealType,
+		MealDate:       time.Now(),
+		FoodID:         fdcID,
+		FoodName:       food.Description,
+		Amount:         amount,
+		Proteins:       mealProteins,
+		Carbs:          mealCarbs,
+		Fats:           mealFats,
+		Calories:       mealCalories,
+		Fiber:          mealFiber,
+		Micronutrients: food.MicronutrientsFor(amount),
 	}
 
 	// Vérifier que les valeurs sont correctes avant de les enregistrer
@@ -290,7 +489,140 @@ func handleAdd(client *fdc.Client, args []string) {
 		return
 	}
 
-	fmt.Printf("Aliment ajouté avec succès au repas: %s\n", mealType)
+	fmt.Printf("Aliment ajouté avec succès au repas: %s (%.0fg)\n", mealType, amount)
+}
+
+// Ajoute une quantité d'une recette, en grammes ou en portions
+func handleAddRecipe(recipeID int, amount float64, unit, mealType string) {
+	recipe, err := db.GetRecipe(currentUser.ID, recipeID)
+	if err != nil {
+		fmt.Println("Recette non trouvée")
+		return
+	}
+
+	if database.IsServingUnit(unit) {
+		amount *= recipe.ServingWeight()
+	} else if grams, ok := fdc.MassUnitGrams(unit); ok {
+		amount *= grams
+	} else {
+		fmt.Println("Unité invalide. Utilisez: g ou portion")
+		return
+	}
+
+	meal := recipe.NewMeal(currentUser.ID, mealType, time.Now(), amount)
+	err = db.AddMeal(&meal)
+	if err != nil {
+		fmt.Printf("Erreur lors de l'ajout du repas: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Recette '%s' ajoutée avec succès au repas: %s (%.0fg, %.0f kcal)\n", recipe.Name, mealType, meal.Amount, meal.Calories)
+}
+
+// Ajoute une quantité (en grammes) d'un aliment personnalisé
+func handleAddCustomFood(foodID int, amount float64, mealType string) {
+	food, err := db.GetCustomFood(currentUser.ID, foodID)
+	if err != nil {
+		fmt.Println("Aliment personnalisé non trouvé")
+		return
+	}
+
+	meal := food.NewMeal(currentUser.ID, mealType, time.Now(), amount)
+	err = db.AddMeal(&meal)
+	if err != nil {
+		fmt.Printf("Erreur lors de l'ajout du repas: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Aliment ajouté avec succès au repas: %s (%.0fg, %.0f kcal)\n", mealType, meal.Amount, meal.Calories)
+}
+
+// Modifie la quantité, le type de repas ou la date d'un aliment consommé
+func handleEdit(scanner *bufio.Reader, idStr string) {
+	mealID, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID de repas invalide")
+		return
+	}
+
+	meal, err := db.GetMeal(currentUser.ID, mealID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			fmt.Println("Repas non trouvé")
+			return
+		}
+		fmt.Printf("Erreur lors de la récupération du repas: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nModification de: %s\n", meal.FoodName)
+
+	fmt.Printf("Quantité actuelle: %.0fg\n", meal.Amount)
+	fmt.Print("Nouvelle quantité (laisser vide pour conserver): ")
+	amountStr, _ := scanner.ReadString('\n')
+	amountStr = strings.TrimSpace(amountStr)
+	if amountStr != "" {
+		amount, err := strconv.ParseFloat(amountStr, 64)
+		if err != nil || amount <= 0 {
+			fmt.Println("Quantité invalide")
+			return
+		}
+		meal.ScaleTo(amount)
+	}
+
+	fmt.Printf("Type de repas actuel: %s\n", meal.MealType)
+	fmt.Print("Nouveau type de repas (petit-dejeuner, dejeuner, diner, collation): ")
+	mealType, _ := scanner.ReadString('\n')
+	mealType = strings.TrimSpace(mealType)
+	if mealType != "" {
+		if !validMealTypes[mealType] {
+			fmt.Println("Type de repas invalide. Utilisez: petit-dejeuner, dejeuner, diner, ou collation")
+			return
+		}
+		meal.MealType = mealType
+	}
+
+	fmt.Printf("Date actuelle: %s\n", meal.MealDate.Format("02/01/2006 15:04"))
+	fmt.Print("Nouvelle date (JJ/MM/AAAA HH:MM): ")
+	dateStr, _ := scanner.ReadString('\n')
+	dateStr = strings.TrimSpace(dateStr)
+	if dateStr != "" {
+		date, err := time.ParseInLocation("02/01/2006 15:04", dateStr, time.Local)
+		if err != nil {
+			fmt.Println("Date invalide")
+			return
+		}
+		meal.MealDate = date
+	}
+
+	err = db.UpdateMeal(meal)
+	if err != nil {
+		fmt.Printf("Erreur lors de la mise à jour du repas: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Repas mis à jour: %s (%.0fg, %.0f kcal)\n", meal.FoodName, meal.Amount, meal.Calories)
+}
+
+// Supprime un aliment consommé
+func handleDelete(idStr string) {
+	mealID, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID de repas invalide")
+		return
+	}
+
+	err = db.DeleteMeal(currentUser.ID, mealID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			fmt.Println("Repas non trouvé")
+			return
+		}
+		fmt.Printf("Erreur lors de la suppression du repas: %v\n", err)
+		return
+	}
+
+	fmt.Println("Repas supprimé avec succès")
 }
 
 func handleReport() {
@@ -302,76 +634,71 @@ func handleReport() {
 		return
 	}
 
-	proteins, carbs, fats, calories, fiber, err := db.GetDailyTotals(currentUser.ID, today)
+	targets, err := report.ParseTargets(currentUser.TargetMacros)
 	if err != nil {
-		fmt.Printf("Erreur lors du calcul des totaux: %v\n", err)
-		return
+		fmt.Printf("Erreur lors de la lecture des objectifs: %v\n", err)
 	}
 
+	daily := report.Daily(today, meals, targets)
+	totals := daily.Totals
+
 	fmt.Printf("\nBilan nutritionnel du %s:\n", today.Format("02/01/2006"))
 	fmt.Println("\nRepas de la journée:")
-	for _, meal := range meals {
-		fmt.Printf("- %s: %s (%.0fg)\n", meal.MealType, meal.FoodName, meal.Amount)
+	for _, group := range daily.Meals {
+		for _, meal := range group.Entries {
+			fmt.Printf("- [%d] %s: %s (%.0fg)\n", meal.ID, meal.MealType, meal.FoodName, meal.Amount)
+		}
 	}
 
 	fmt.Println("\nTotaux journaliers:")
-	fmt.Printf("- Calories: %.0f kcal\n", calories)
-	fmt.Printf("- Protéines: %.1fg\n", proteins)
-	fmt.Printf("- Glucides: %.1fg\n", carbs)
-	fmt.Printf("- Lipides: %.1fg\n", fats)
-	fmt.Printf("- Fibres: %.1fg\n", fiber)
+	fmt.Printf("- Calories: %.0f kcal\n", totals.Calories)
+	fmt.Printf("- Protéines: %.1fg\n", totals.Proteins)
+	fmt.Printf("- Glucides: %.1fg\n", totals.Carbs)
+	fmt.Printf("- Lipides: %.1fg\n", totals.Fats)
+	fmt.Printf("- Fibres: %.1fg\n", totals.Fiber)
+	printMicronutrients(daily.Micronutrients)
+
+	if daily.Percent != nil {
+		percent := daily.Percent
+		fmt.Println("\nComparaison avec vos objectifs:")
+		fmt.Printf("- Calories: %.0f/%.0f kcal (%.0f%%)\n",
+			totals.Calories, targets.Calories, percent.Calories)
+		fmt.Printf("- Protéines: %.1f/%.1fg (%.0f%%)\n",
+			totals.Proteins, targets.Proteins, percent.Proteins)
+		fmt.Printf("- Glucides: %.1f/%.1fg (%.0f%%)\n",
+			totals.Carbs, targets.Carbs, percent.Carbs)
+		fmt.Printf("- Lipides: %.1f/%.1fg (%.0f%%)\n",
+			totals.Fats, targets.Fats, percent.Fats)
+		fmt.Printf("- Fibres: %.1f/%.1fg (%.0f%%)\n",
+			totals.Fiber, targets.Fiber, percent.Fiber)
+	}
+}
 
-	var targets MacroTargets
-	if len(currentUser.TargetMacros) > 0 {
-		err := json.Unmarshal(currentUser.TargetMacros, &targets)
-		if err == nil && targets.Calories > 0 {
-			fmt.Println("\nComparaison avec vos objectifs:")
-			
-			caloriePercent := 0.0
-			if targets.Calories > 0 {
-				caloriePercent = calories/targets.Calories*100
-			}
-			
-			proteinPercent := 0.0
-			if targets.Proteins > 0 {
-				proteinPercent = proteins/targets.Proteins*100
-			}
-			
-			carbPercent := 0.0
-			if targets.Carbs > 0 {
-				carbPercent = carbs/targets.Carbs*100
-			}
-			
-			fatPercent := 0.0
-			if targets.Fats > 0 {
-				fatPercent = fats/targets.Fats*100
-			}
-			
-			fiberPercent := 0.0
-			if targets.Fiber > 0 {
-				fiberPercent = fiber/targets.Fiber*100
-			}
-			
-			fmt.Printf("- Calories: %.0f/%.0f kcal (%.0f%%)\n", 
-				calories, targets.Calories, caloriePercent)
-			fmt.Printf("- Protéines: %.1f/%.1fg (%.0f%%)\n", 
-				proteins, targets.Proteins, proteinPercent)
-			fmt.Printf("- Glucides: %.1f/%.1fg (%.0f%%)\n", 
-				carbs, targets.Carbs, carbPercent)
-			fmt.Printf("- Lipides: %.1f/%.1fg (%.0f%%)\n", 
-				fats, targets.Fats, fatPercent)
-			fmt.Printf("- Fibres: %.1f/%.1fg (%.0f%%)\n", 
-				fiber, targets.Fiber, fiberPercent)
+// Affiche les micronutriments connus, dans l'ordre de fdc.Micronutrients
+func printMicronutrients(values database.Micronutrients) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Println("\nMicronutriments:")
+	for _, m := range fdc.Micronutrients {
+		if value, ok := values[string(m.Key)]; ok {
+			fmt.Printf("- %s: %.1f%s\n", m.Label, value, m.Unit)
 		}
 	}
 }
 
-func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Client, user *database.User) {
+func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient fdc.Provider, user *database.User) {
 	fmt.Print("\nGestion des journées types\n")
 	fmt.Print("1. Créer une journée type\n")
 	fmt.Print("2. Voir les journées types\n")
 	fmt.Print("3. Ajouter un repas à une journée type\n")
-	fmt.Print("Choisissez une option (1-3) : ")
+	fmt.Print("4. Appliquer une journée type à une date\n")
+	fmt.Print("5. Modifier une journée type\n")
+	fmt.Print("6. Dupliquer une journée type\n")
+	fmt.Print("7. Supprimer une journée type\n")
+	fmt.Print("8. Générer une journée type selon vos objectifs\n")
+	fmt.Print("Choisissez une option (1-8) : ")
 
 	option, _ := reader.ReadString('\n')
 	option = strings.TrimSpace(option)
@@ -412,6 +739,11 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 			return
 		}
 
+		targets, err := report.ParseTargets(user.TargetMacros)
+		if err != nil {
+			fmt.Printf("Erreur lors de la lecture des objectifs : %v\n", err)
+		}
+
 		fmt.Println("\nJournées types :")
 		for _, plan := range plans {
 			fmt.Printf("\n%d. %s\n", plan.ID, plan.Name)
@@ -428,45 +760,13 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 				for _, item := range items {
 					fmt.Printf("   - %s : %s (%.0fg)\n", item.MealType, item.FoodName, item.Amount)
 				}
+				printPlanSummary(report.SummarizePlan(items, targets))
 			}
 		}
 
 	case "3":
-		plans, err := db.GetMealPlans(user.ID)
-		if err != nil {
-			fmt.Printf("Erreur lors de la récupération des journées types : %v\n", err)
-			return
-		}
-
-		if len(plans) == 0 {
-			fmt.Println("Aucune journée type trouvée. Créez-en une d'abord.")
-			return
-		}
-
-		fmt.Println("\nChoisissez une journée type :")
-		for _, plan := range plans {
-			fmt.Printf("%d. %s\n", plan.ID, plan.Name)
-		}
-
-		fmt.Print("Numéro de la journée type : ")
-		planIDStr, _ := reader.ReadString('\n')
-		planIDStr = strings.TrimSpace(planIDStr)
-		planID, err := strconv.Atoi(planIDStr)
-		if err != nil {
-			fmt.Println("Numéro de journée type invalide.")
-			return
-		}
-
-		var selectedPlan *database.MealPlan
-		for _, plan := range plans {
-			if plan.ID == planID {
-				selectedPlan = &plan
-				break
-			}
-		}
-
+		selectedPlan := selectMealPlan(reader, db, user)
 		if selectedPlan == nil {
-			fmt.Println("Journée type non trouvée.")
 			return
 		}
 
@@ -502,7 +802,7 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 		query, _ := reader.ReadString('\n')
 		query = strings.TrimSpace(query)
 
-		result, err := fdcClient.SearchFoods(query)
+		result, err := fdcClient.SearchFoodsContext(context.Background(), query)
 		if err != nil {
 			fmt.Printf("Erreur lors de la recherche : %v\n", err)
 			return
@@ -542,7 +842,7 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 		multiplier := amount / 100.0
 
 		item := &database.MealPlanItem{
-			MealPlanID: planID,
+			MealPlanID: selectedPlan.ID,
 			MealType:   mealType,
 			FoodID:     selectedFood.FdcID,
 			FoodName:   selectedFood.Description,
@@ -552,6 +852,8 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 			Fats:       fats * multiplier,
 			Calories:   calories * multiplier,
 			Fiber:      fiber * multiplier,
+
+			Micronutrients: selectedFood.MicronutrientsFor(amount),
 		}
 
 		err = db.AddMealPlanItem(item)
@@ -562,56 +864,751 @@ func handlePlanCommand(reader *bufio.Reader, db *database.DB, fdcClient *fdc.Cli
 
 		fmt.Printf("\nRepas ajouté avec succès à la journée type '%s' !\n", selectedPlan.Name)
 
-	default:
-		fmt.Println("Option invalide.")
-	}
-}
+	case "4":
+		selectedPlan := selectMealPlan(reader, db, user)
+		if selectedPlan == nil {
+			return
+		}
 
-// Calcule l'IMC (Indice de Masse Corporelle)
-func calculateBMI(weight, height float64) float64 {
-	// Hauteur en mètres
-	heightInMeters := height / 100
-	return weight / (heightInMeters * heightInMeters)
-}
+		fmt.Print("Date (JJ/MM/AAAA, laisser vide pour aujourd'hui) : ")
+		dateStr, _ := reader.ReadString('\n')
+		dateStr = strings.TrimSpace(dateStr)
+		date := time.Now()
+		if dateStr != "" {
+			parsed, err := time.ParseInLocation("02/01/2006", dateStr, time.Local)
+			if err != nil {
+				fmt.Println("Date invalide.")
+				return
+			}
+			date = parsed
+		}
 
-// Calcule le taux de masse grasse (formule simplifiée)
-// Utilise la formule de l'US Navy
-func calculateBodyFat(user *database.User) float64 {
-	// Implémentation basique, à améliorer avec des formules plus précises
-	bmi := calculateBMI(user.Weight, user.Height)
-	
-	// Facteur d'âge (simplifié)
-	ageFactor := float64(user.Age) * 0.12
-	
-	if user.Gender == "homme" {
-		return (1.20 * bmi) + (0.23 * ageFactor) - 16.2
-	} else {
-		return (1.20 * bmi) + (0.23 * ageFactor) - 5.4
-	}
-}
+		fmt.Print("Facteur de quantité (laisser vide pour 1) : ")
+		factorStr, _ := reader.ReadString('\n')
+		factorStr = strings.TrimSpace(factorStr)
+		factor := 1.0
+		if factorStr != "" {
+			parsed, err := strconv.ParseFloat(factorStr, 64)
+			if err != nil || parsed <= 0 {
+				fmt.Println("Facteur invalide.")
+				return
+			}
+			factor = parsed
+		}
 
-// Affiche les informations de santé
-func handleHealth() {
-	bmi := calculateBMI(currentUser.Weight, currentUser.Height)
-	bodyFat := calculateBodyFat(currentUser)
-	
-	fmt.Println("\nInformations de santé:")
-	fmt.Printf("- Poids: %.1f kg\n", currentUser.Weight)
-	fmt.Printf("- Taille: %.1f cm\n", currentUser.Height)
-	fmt.Printf("- IMC: %.1f\n", bmi)
-	
-	// Interprétation de l'IMC
-	var interpretation string
-	switch {
-	case bmi < 18.5:
-		interpretation = "Insuffisance pondérale"
-	case bmi < 25:
-		interpretation = "Corpulence normale"
-	case bmi < 30:
-		interpretation = "Surpoids"
-	default:
-		interpretation = "Obésité"
-	}
+		meals, err := db.ApplyMealPlan(selectedPlan.ID, user.ID, date, factor)
+		if err != nil {
+			fmt.Printf("Erreur lors de l'application de la journée type : %v\n", err)
+			return
+		}
+
+		fmt.Printf("\n%d aliments de '%s' ajoutés au %s !\n", len(meals), selectedPlan.Name, date.Format("02/01/2006"))
+
+	case "5":
+		selectedPlan := selectMealPlan(reader, db, user)
+		if selectedPlan == nil {
+			return
+		}
+
+		fmt.Printf("Nom actuel : %s\n", selectedPlan.Name)
+		fmt.Print("Nouveau nom (laisser vide pour conserver) : ")
+		name, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(name)
+		if name != "" {
+			selectedPlan.Name = name
+		}
+
+		fmt.Printf("Description actuelle : %s\n", selectedPlan.Description)
+		fmt.Print("Nouvelle description (laisser vide pour conserver) : ")
+		description, _ := reader.ReadString('\n')
+		description = strings.TrimSpace(description)
+		if description != "" {
+			selectedPlan.Description = description
+		}
+
+		err := db.UpdateMealPlan(selectedPlan)
+		if err != nil {
+			fmt.Printf("Erreur lors de la modification de la journée type : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Journée type '%s' modifiée avec succès !\n", selectedPlan.Name)
+
+	case "6":
+		selectedPlan := selectMealPlan(reader, db, user)
+		if selectedPlan == nil {
+			return
+		}
+
+		fmt.Printf("Nom de la copie (laisser vide pour '%s (copie)') : ", selectedPlan.Name)
+		name, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = selectedPlan.Name + " (copie)"
+		}
+
+		plan, err := db.DuplicateMealPlan(selectedPlan.ID, name)
+		if err != nil {
+			fmt.Printf("Erreur lors de la duplication de la journée type : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Journée type '%s' créée avec succès (numéro %d) !\n", plan.Name, plan.ID)
+
+	case "7":
+		selectedPlan := selectMealPlan(reader, db, user)
+		if selectedPlan == nil {
+			return
+		}
+
+		fmt.Printf("Supprimer '%s' et tous ses repas ? (o/n) : ", selectedPlan.Name)
+		confirm, _ := reader.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(confirm)) != "o" {
+			fmt.Println("Suppression annulée.")
+			return
+		}
+
+		err := db.DeleteMealPlan(selectedPlan.ID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la suppression de la journée type : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Journée type '%s' supprimée avec succès !\n", selectedPlan.Name)
+
+	case "8":
+		handleGeneratePlan(reader, db, fdcClient, user)
+
+	default:
+		fmt.Println("Option invalide.")
+	}
+}
+
+// Génère une journée type dont les quantités approchent les objectifs de
+// l'utilisateur, à partir de son historique ou d'aliments FDC choisis
+func handleGeneratePlan(reader *bufio.Reader, db *database.DB, fdcClient fdc.Provider, user *database.User) {
+	targets, err := report.ParseTargets(user.TargetMacros)
+	if err != nil || !targets.IsSet() {
+		fmt.Println("Définissez d'abord vos objectifs avec 'goals set'.")
+		return
+	}
+
+	fmt.Print("Nom de la journée type : ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Println("Nom invalide.")
+		return
+	}
+
+	fmt.Println("\nAliments à utiliser :")
+	fmt.Println("1. Mes aliments les plus fréquents")
+	fmt.Println("2. Des aliments FDC choisis par identifiant")
+	fmt.Print("Choisissez une option (1-2) : ")
+	source, _ := reader.ReadString('\n')
+
+	var candidates []planner.Candidate
+	switch strings.TrimSpace(source) {
+	case "1":
+		foods, err := db.GetFrequentFoods(user.ID, 100)
+		if err != nil {
+			fmt.Printf("Erreur lors de la lecture de l'historique : %v\n", err)
+			return
+		}
+		candidates = planner.FromHistory(foods, 3)
+
+	case "2":
+		mealTypes := []database.MealType{database.Breakfast, database.Snack1, database.Lunch, database.Snack2, database.Dinner}
+		for _, mealType := range mealTypes {
+			fmt.Printf("IDs FDC pour %s (séparés par des virgules, vide pour aucun) : ", mealType)
+			idsStr, _ := reader.ReadString('\n')
+			for _, idStr := range strings.Split(idsStr, ",") {
+				idStr = strings.TrimSpace(idStr)
+				if idStr == "" {
+					continue
+				}
+				fdcID, err := strconv.Atoi(idStr)
+				if err != nil {
+					fmt.Printf("ID invalide ignoré : %s\n", idStr)
+					continue
+				}
+				food, err := fdcClient.GetFoodContext(context.Background(), fdcID)
+				if err != nil {
+					fmt.Printf("Aliment %d ignoré : %v\n", fdcID, err)
+					continue
+				}
+				candidates = append(candidates, planner.FromFood(food, mealType))
+			}
+		}
+
+	default:
+		fmt.Println("Option invalide.")
+		return
+	}
+
+	items, err := planner.Generate(targets, candidates, nil)
+	if err != nil {
+		fmt.Printf("Impossible de générer la journée type : %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nJournée type proposée '%s' :\n", name)
+	for _, item := range items {
+		fmt.Printf("   - %s : %s (%.0fg)\n", item.MealType, item.FoodName, item.Amount)
+	}
+	printPlanSummary(report.SummarizePlan(items, targets))
+
+	fmt.Print("\nEnregistrer cette journée type ? (o/n) : ")
+	confirm, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirm)) != "o" {
+		fmt.Println("Journée type non enregistrée.")
+		return
+	}
+
+	plan := &database.MealPlan{
+		UserID:      user.ID,
+		Name:        name,
+		Description: "Générée automatiquement selon vos objectifs",
+	}
+	if err := db.CreateMealPlanWithItems(plan, items); err != nil {
+		fmt.Printf("Erreur lors de l'enregistrement de la journée type : %v\n", err)
+		return
+	}
+
+	fmt.Printf("Journée type '%s' créée avec succès !\n", plan.Name)
+}
+
+// Affiche les totaux d'une journée type et leur écart aux objectifs
+func printPlanSummary(summary report.PlanSummary) {
+	fmt.Println("   Totaux par repas :")
+	for _, meal := range summary.ByMealType {
+		fmt.Printf("   - %s : %.0f kcal, P:%.1fg, C:%.1fg, L:%.1fg\n",
+			meal.MealType, meal.Totals.Calories, meal.Totals.Proteins, meal.Totals.Carbs, meal.Totals.Fats)
+	}
+
+	totals := summary.Totals
+	fmt.Printf("   Total : %.0f kcal, P:%.1fg, C:%.1fg, L:%.1fg, F:%.1fg\n",
+		totals.Calories, totals.Proteins, totals.Carbs, totals.Fats, totals.Fiber)
+
+	if summary.Deviation != nil {
+		deviation := summary.Deviation
+		fmt.Printf("   Écart aux objectifs : %+.0f kcal, P:%+.1fg, C:%+.1fg, L:%+.1fg, F:%+.1fg\n",
+			deviation.Calories, deviation.Proteins, deviation.Carbs, deviation.Fats, deviation.Fiber)
+	}
+
+	for _, warning := range summary.Warnings {
+		fmt.Printf("   Attention : %s\n", warning)
+	}
+}
+
+// Affiche les journées types de l'utilisateur et renvoie celle choisie,
+// ou nil si la saisie est invalide
+func selectMealPlan(reader *bufio.Reader, db *database.DB, user *database.User) *database.MealPlan {
+	plans, err := db.GetMealPlans(user.ID)
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des journées types : %v\n", err)
+		return nil
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("Aucune journée type trouvée. Créez-en une d'abord.")
+		return nil
+	}
+
+	fmt.Println("\nChoisissez une journée type :")
+	for _, plan := range plans {
+		fmt.Printf("%d. %s\n", plan.ID, plan.Name)
+	}
+
+	fmt.Print("Numéro de la journée type : ")
+	planIDStr, _ := reader.ReadString('\n')
+	planID, err := strconv.Atoi(strings.TrimSpace(planIDStr))
+	if err != nil {
+		fmt.Println("Numéro de journée type invalide.")
+		return nil
+	}
+
+	for i := range plans {
+		if plans[i].ID == planID {
+			return &plans[i]
+		}
+	}
+
+	fmt.Println("Journée type non trouvée.")
+	return nil
+}
+
+func handleRecipeCommand(reader *bufio.Reader, fdcClient fdc.Provider) {
+	fmt.Print("\nGestion des recettes\n")
+	fmt.Print("1. Créer une recette\n")
+	fmt.Print("2. Voir les recettes\n")
+	fmt.Print("3. Modifier une recette\n")
+	fmt.Print("4. Supprimer une recette\n")
+	fmt.Print("Choisissez une option (1-4) : ")
+
+	option, _ := reader.ReadString('\n')
+	option = strings.TrimSpace(option)
+
+	switch option {
+	case "1":
+		recipe := &database.Recipe{UserID: currentUser.ID}
+		if !readRecipe(reader, recipe) {
+			return
+		}
+
+		fmt.Println("Ingrédients (ID FDC et quantité en grammes, ex: 173944 150), ligne vide pour terminer :")
+		recipe.Ingredients = readIngredients(reader, fdcClient)
+		if len(recipe.Ingredients) == 0 {
+			fmt.Println("La recette doit contenir au moins un ingrédient.")
+			return
+		}
+
+		err := db.CreateRecipe(recipe)
+		if err != nil {
+			fmt.Printf("Erreur lors de la création de la recette : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Recette '%s' créée avec succès (ID %d) !\n", recipe.Name, recipe.ID)
+		printRecipe(recipe)
+
+	case "2":
+		recipes, err := db.GetRecipes(currentUser.ID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la récupération des recettes : %v\n", err)
+			return
+		}
+
+		if len(recipes) == 0 {
+			fmt.Println("Aucune recette trouvée.")
+			return
+		}
+
+		fmt.Println("\nRecettes :")
+		for i := range recipes {
+			fmt.Printf("\n%d. %s\n", recipes[i].ID, recipes[i].Name)
+			printRecipe(&recipes[i])
+		}
+
+	case "3":
+		recipe := selectRecipe(reader)
+		if recipe == nil {
+			return
+		}
+
+		fmt.Println("Laissez vide pour conserver la valeur actuelle.")
+		if !readRecipe(reader, recipe) {
+			return
+		}
+
+		fmt.Print("Remplacer les ingrédients ? (o/N) : ")
+		answer, _ := reader.ReadString('\n')
+		if strings.EqualFold(strings.TrimSpace(answer), "o") {
+			fmt.Println("Ingrédients (ID FDC et quantité en grammes, ex: 173944 150), ligne vide pour terminer :")
+			ingredients := readIngredients(reader, fdcClient)
+			if len(ingredients) == 0 {
+				fmt.Println("La recette doit contenir au moins un ingrédient.")
+				return
+			}
+			recipe.Ingredients = ingredients
+		}
+
+		err := db.UpdateRecipe(recipe)
+		if err != nil {
+			fmt.Printf("Erreur lors de la mise à jour de la recette : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Recette '%s' mise à jour.\n", recipe.Name)
+		printRecipe(recipe)
+
+	case "4":
+		recipe := selectRecipe(reader)
+		if recipe == nil {
+			return
+		}
+
+		err := db.DeleteRecipe(currentUser.ID, recipe.ID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la suppression de la recette : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Recette '%s' supprimée.\n", recipe.Name)
+
+	default:
+		fmt.Println("Option invalide.")
+	}
+}
+
+// Lit le nom, le poids après cuisson et le nombre de portions d'une recette ;
+// une saisie vide conserve la valeur actuelle
+func readRecipe(reader *bufio.Reader, recipe *database.Recipe) bool {
+	fmt.Print("Nom de la recette : ")
+	name, _ := reader.ReadString('\n')
+	if name = strings.TrimSpace(name); name != "" {
+		recipe.Name = name
+	}
+	if recipe.Name == "" {
+		fmt.Println("Le nom de la recette est obligatoire.")
+		return false
+	}
+
+	fmt.Print("Poids total après cuisson en grammes (vide : somme des ingrédients) : ")
+	weightStr, _ := reader.ReadString('\n')
+	if weightStr = strings.TrimSpace(weightStr); weightStr != "" {
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil || weight < 0 {
+			fmt.Println("Poids invalide.")
+			return false
+		}
+		recipe.CookedWeight = weight
+	}
+
+	fmt.Print("Nombre de portions : ")
+	servingsStr, _ := reader.ReadString('\n')
+	if servingsStr = strings.TrimSpace(servingsStr); servingsStr != "" {
+		servings, err := strconv.ParseFloat(servingsStr, 64)
+		if err != nil || servings <= 0 {
+			fmt.Println("Nombre de portions invalide.")
+			return false
+		}
+		recipe.Servings = servings
+	}
+	if recipe.Servings <= 0 {
+		recipe.Servings = 1
+	}
+	return true
+}
+
+// Lit les ingrédients d'une recette jusqu'à une ligne vide et récupère leurs
+// nutriments auprès de l'API FDC
+func readIngredients(reader *bufio.Reader, fdcClient fdc.Provider) []database.RecipeIngredient {
+	var ingredients []database.RecipeIngredient
+	for {
+		fmt.Print("Ingrédient : ")
+		line, _ := reader.ReadString('\n')
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return ingredients
+		}
+
+		if len(fields) != 2 {
+			fmt.Println("Format attendu : <ID FDC> <quantité en grammes>")
+			continue
+		}
+		foodID, err := strconv.Atoi(fields[0])
+		if err != nil || foodID <= 0 {
+			fmt.Println("ID d'aliment invalide")
+			continue
+		}
+		amount, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || amount <= 0 {
+			fmt.Println("Quantité invalide")
+			continue
+		}
+
+		food, err := fdcClient.GetFoodContext(context.Background(), foodID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la récupération de l'aliment : %v\n", err)
+			continue
+		}
+
+		proteins, carbs, fats, calories, fiber := food.MacrosFor(amount)
+		ingredients = append(ingredients, database.RecipeIngredient{
+			FoodID:         food.FdcID,
+			FoodName:       food.Description,
+			Amount:         amount,
+			Proteins:       proteins,
+			Carbs:          carbs,
+			Fats:           fats,
+			Calories:       calories,
+			Fiber:          fiber,
+			Micronutrients: food.MicronutrientsFor(amount),
+		})
+		fmt.Printf("- %s (%.0fg) ajouté\n", food.Description, amount)
+	}
+}
+
+// Affiche les ingrédients d'une recette et ses valeurs pour 100g et par portion
+func printRecipe(recipe *database.Recipe) {
+	fmt.Printf("   Poids : %.0fg, %g portion(s) de %.0fg\n", recipe.Weight(), recipe.Servings, recipe.ServingWeight())
+	fmt.Println("   Ingrédients :")
+	for _, ingredient := range recipe.Ingredients {
+		fmt.Printf("   - %s (%.0fg)\n", ingredient.FoodName, ingredient.Amount)
+	}
+
+	proteins, carbs, fats, calories, fiber := recipe.MacrosFor(100)
+	fmt.Printf("   Pour 100g : %.0f kcal, P %.1fg, G %.1fg, L %.1fg, F %.1fg\n",
+		calories, proteins, carbs, fats, fiber)
+	proteins, carbs, fats, calories, fiber = recipe.MacrosFor(recipe.ServingWeight())
+	fmt.Printf("   Par portion : %.0f kcal, P %.1fg, G %.1fg, L %.1fg, F %.1fg\n",
+		calories, proteins, carbs, fats, fiber)
+}
+
+// Affiche les recettes de l'utilisateur et renvoie celle choisie, ou nil si
+// la saisie est invalide
+func selectRecipe(reader *bufio.Reader) *database.Recipe {
+	recipes, err := db.GetRecipes(currentUser.ID)
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des recettes : %v\n", err)
+		return nil
+	}
+
+	if len(recipes) == 0 {
+		fmt.Println("Aucune recette trouvée. Créez-en une d'abord.")
+		return nil
+	}
+
+	fmt.Println("\nChoisissez une recette :")
+	for _, recipe := range recipes {
+		fmt.Printf("%d. %s\n", recipe.ID, recipe.Name)
+	}
+
+	fmt.Print("Numéro de la recette : ")
+	recipeIDStr, _ := reader.ReadString('\n')
+	recipeID, err := strconv.Atoi(strings.TrimSpace(recipeIDStr))
+	if err != nil {
+		fmt.Println("Numéro de recette invalide.")
+		return nil
+	}
+
+	for i := range recipes {
+		if recipes[i].ID == recipeID {
+			return &recipes[i]
+		}
+	}
+
+	fmt.Println("Recette non trouvée.")
+	return nil
+}
+
+func handleFoodCommand(reader *bufio.Reader) {
+	fmt.Print("\nGestion des aliments personnalisés\n")
+	fmt.Print("1. Créer un aliment\n")
+	fmt.Print("2. Voir les aliments\n")
+	fmt.Print("3. Modifier un aliment\n")
+	fmt.Print("4. Supprimer un aliment\n")
+	fmt.Print("Choisissez une option (1-4) : ")
+
+	option, _ := reader.ReadString('\n')
+	option = strings.TrimSpace(option)
+
+	switch option {
+	case "1":
+		food := &database.CustomFood{UserID: currentUser.ID}
+		if !readCustomFood(reader, food) {
+			return
+		}
+
+		err := db.CreateCustomFood(food)
+		if err != nil {
+			fmt.Printf("Erreur lors de la création de l'aliment : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Aliment '%s' créé avec succès ! Ajoutez-le avec : add %s <quantité> <type de repas>\n",
+			food.Name, foodref.Ref{Source: foodref.Custom, ID: food.ID})
+
+	case "2":
+		foods, err := db.GetCustomFoods(currentUser.ID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la récupération des aliments : %v\n", err)
+			return
+		}
+
+		if len(foods) == 0 {
+			fmt.Println("Aucun aliment personnalisé trouvé.")
+			return
+		}
+
+		fmt.Println("\nAliments personnalisés (valeurs pour 100g) :")
+		for _, food := range foods {
+			fmt.Printf("- %s : %s\n", foodref.Ref{Source: foodref.Custom, ID: food.ID}, customFoodLabel(&food))
+			fmt.Printf("  %.0f kcal, P %.1fg, G %.1fg, L %.1fg, F %.1fg\n",
+				food.Calories, food.Proteins, food.Carbs, food.Fats, food.Fiber)
+		}
+
+	case "3":
+		food := selectCustomFood(reader)
+		if food == nil {
+			return
+		}
+
+		fmt.Println("Laissez vide pour conserver la valeur actuelle.")
+		if !readCustomFood(reader, food) {
+			return
+		}
+
+		err := db.UpdateCustomFood(food)
+		if err != nil {
+			fmt.Printf("Erreur lors de la mise à jour de l'aliment : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Aliment '%s' mis à jour.\n", food.Name)
+
+	case "4":
+		food := selectCustomFood(reader)
+		if food == nil {
+			return
+		}
+
+		err := db.DeleteCustomFood(currentUser.ID, food.ID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la suppression de l'aliment : %v\n", err)
+			return
+		}
+
+		fmt.Printf("Aliment '%s' supprimé.\n", food.Name)
+
+	default:
+		fmt.Println("Option invalide.")
+	}
+}
+
+// Renvoie le nom d'un aliment personnalisé suivi de sa marque, si elle est connue
+func customFoodLabel(food *database.CustomFood) string {
+	if food.Brand == "" {
+		return food.Name
+	}
+	return fmt.Sprintf("%s (%s)", food.Name, food.Brand)
+}
+
+// Lit le nom, la marque et les nutriments pour 100g d'un aliment
+// personnalisé ; une saisie vide conserve la valeur actuelle
+func readCustomFood(reader *bufio.Reader, food *database.CustomFood) bool {
+	fmt.Print("Nom de l'aliment : ")
+	name, _ := reader.ReadString('\n')
+	if name = strings.TrimSpace(name); name != "" {
+		food.Name = name
+	}
+	if food.Name == "" {
+		fmt.Println("Le nom de l'aliment est obligatoire.")
+		return false
+	}
+
+	fmt.Print("Marque (facultatif) : ")
+	brand, _ := reader.ReadString('\n')
+	if brand = strings.TrimSpace(brand); brand != "" {
+		food.Brand = brand
+	}
+
+	fmt.Println("Valeurs nutritionnelles pour 100g :")
+	values := []struct {
+		label string
+		value *float64
+	}{
+		{"Calories (kcal)", &food.Calories},
+		{"Protéines (g)", &food.Proteins},
+		{"Glucides (g)", &food.Carbs},
+		{"Lipides (g)", &food.Fats},
+		{"Fibres (g)", &food.Fiber},
+	}
+	for _, v := range values {
+		fmt.Printf("%s : ", v.label)
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(input, 64)
+		if err != nil || value < 0 {
+			fmt.Println("Valeur invalide.")
+			return false
+		}
+		*v.value = value
+	}
+
+	if food.Proteins+food.Carbs+food.Fats+food.Fiber > 100 {
+		fmt.Println("La somme des nutriments ne peut pas dépasser 100g pour 100g.")
+		return false
+	}
+	return true
+}
+
+// Affiche les aliments personnalisés de l'utilisateur et renvoie celui
+// choisi, ou nil si la saisie est invalide
+func selectCustomFood(reader *bufio.Reader) *database.CustomFood {
+	foods, err := db.GetCustomFoods(currentUser.ID)
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des aliments : %v\n", err)
+		return nil
+	}
+
+	if len(foods) == 0 {
+		fmt.Println("Aucun aliment personnalisé trouvé. Créez-en un d'abord.")
+		return nil
+	}
+
+	fmt.Println("\nChoisissez un aliment :")
+	for _, food := range foods {
+		fmt.Printf("%d. %s\n", food.ID, customFoodLabel(&food))
+	}
+
+	fmt.Print("Numéro de l'aliment : ")
+	foodIDStr, _ := reader.ReadString('\n')
+	foodID, err := strconv.Atoi(strings.TrimSpace(foodIDStr))
+	if err != nil {
+		fmt.Println("Numéro d'aliment invalide.")
+		return nil
+	}
+
+	for i := range foods {
+		if foods[i].ID == foodID {
+			return &foods[i]
+		}
+	}
+
+	fmt.Println("Aliment non trouvé.")
+	return nil
+}
+
+// Calcule l'IMC (Indice de Masse Corporelle)
+func calculateBMI(weight, height float64) float64 {
+	// Hauteur en mètres
+	heightInMeters := height / 100
+	return weight / (heightInMeters * heightInMeters)
+}
+
+// Calcule le taux de masse grasse (formule simplifiée)
+// Utilise la formule de l'US Navy
+func calculateBodyFat(user *database.User) float64 {
+	// Implémentation basique, à améliorer avec des formules plus précises
+	bmi := calculateBMI(user.Weight, user.Height)
+	
+	// Facteur d'âge (simplifié)
+	ageFactor := float64(user.Age) * 0.12
+	
+	if user.Gender == "homme" {
+		return (1.20 * bmi) + (0.23 * ageFactor) - 16.2
+	} else {
+		return (1.20 * bmi) + (0.23 * ageFactor) - 5.4
+	}
+}
+
+// Affiche les informations de santé
+func handleHealth() {
+	bmi := calculateBMI(currentUser.Weight, currentUser.Height)
+	bodyFat := calculateBodyFat(currentUser)
+	
+	fmt.Println("\nInformations de santé:")
+	fmt.Printf("- Poids: %.1f kg\n", currentUser.Weight)
+	fmt.Printf("- Taille: %.1f cm\n", currentUser.Height)
+	fmt.Printf("- IMC: %.1f\n", bmi)
+	
+	// Interprétation de l'IMC
+	var interpretation string
+	switch {
+	case bmi < 18.5:
+		interpretation = "Insuffisance pondérale"
+	case bmi < 25:
+		interpretation = "Corpulence normale"
+	case bmi < 30:
+		interpretation = "Surpoids"
+	default:
+		interpretation = "Obésité"
+	}
 	
 	fmt.Printf("  Interprétation: %s\n", interpretation)
 	fmt.Printf("- Taux de masse grasse estimé: %.1f%%\n", bodyFat)
@@ -619,7 +1616,7 @@ func handleHealth() {
 
 // Affiche les objectifs nutritionnels
 func handleGoalsView() {
-	var targets MacroTargets
+	var targets report.Targets
 	
 	// Charger les objectifs depuis la base de données
 	if len(currentUser.TargetMacros) > 0 {
@@ -645,7 +1642,7 @@ func handleGoalsView() {
 
 // Met à jour les objectifs nutritionnels
 func handleGoalsUpdate(scanner *bufio.Reader) {
-	var targets MacroTargets
+	var targets report.Targets
 	
 	fmt.Println("\nDéfinition des objectifs nutritionnels:")
 	
@@ -716,25 +1713,72 @@ func handleHistory(days int) {
 		startDate.Format("02/01/2006"), 
 		endDate.Format("02/01/2006"))
 	
-	// Pour chaque jour
-	for d := 0; d < days; d++ {
-		date := endDate.AddDate(0, 0, -d)
-		proteins, carbs, fats, calories, fiber, err := db.GetDailyTotals(currentUser.ID, date)
-		
-		if err != nil {
-			fmt.Printf("Erreur lors de la récupération des données pour le %s: %v\n", 
-				date.Format("02/01/2006"), err)
-			continue
-		}
-		
-		// N'afficher que les jours avec des données
-		if calories > 0 {
+	totals, err := db.GetDailyTotalsBetweenDates(currentUser.ID, startDate, endDate)
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération de l'historique: %v\n", err)
+		return
+	}
+
+	// Du jour le plus récent au plus ancien, seuls les jours avec des données
+	for i := len(totals) - 1; i >= 0; i-- {
+		day := totals[i]
+		if day.Calories > 0 {
 			fmt.Printf("- %s: %.0f kcal, P:%.1fg, C:%.1fg, L:%.1fg, F:%.1fg\n", 
-				date.Format("02/01/2006"), calories, proteins, carbs, fats, fiber)
+				day.Date.Format("02/01/2006"), day.Calories, day.Proteins, day.Carbs, day.Fats, day.Fiber)
 		}
 	}
 }
 
+// Affiche le bilan de la semaine ou du mois en cours
+func handleSummary(period report.Period) {
+	start, end, err := period.Bounds(time.Now())
+	if err != nil {
+		fmt.Printf("Erreur: %v\n", err)
+		return
+	}
+
+	meals, err := db.GetMealsBetweenDates(currentUser.ID, start, end.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des repas: %v\n", err)
+		return
+	}
+
+	targets, err := report.ParseTargets(currentUser.TargetMacros)
+	if err != nil {
+		fmt.Printf("Erreur lors de la lecture des objectifs: %v\n", err)
+	}
+
+	summary := report.Summarize(start, end, meals, targets)
+
+	fmt.Printf("\nBilan du %s au %s:\n", start.Format("02/01/2006"), end.Format("02/01/2006"))
+	fmt.Printf("- Jours renseignés: %d/%d\n", summary.LoggedDays, summary.Days)
+	if summary.LoggedDays == 0 {
+		fmt.Println("Aucun repas enregistré sur cette période.")
+		return
+	}
+
+	average := summary.Average
+	fmt.Println("\nMoyennes journalières:")
+	fmt.Printf("- Calories: %.0f kcal\n", average.Calories)
+	fmt.Printf("- Protéines: %.1fg\n", average.Proteins)
+	fmt.Printf("- Glucides: %.1fg\n", average.Carbs)
+	fmt.Printf("- Lipides: %.1fg\n", average.Fats)
+	fmt.Printf("- Fibres: %.1fg\n", average.Fiber)
+	printMicronutrients(summary.AverageMicronutrients)
+
+	if summary.Targets == nil {
+		fmt.Println("\nDéfinissez vos objectifs avec 'goals set' pour suivre votre régularité.")
+		return
+	}
+
+	fmt.Printf("\nJours dans l'objectif (±%.0f%% des calories): %d/%d\n",
+		report.GoalTolerance*100, summary.DaysOnTarget, summary.LoggedDays)
+	fmt.Printf("- Meilleur jour: %s (%.0f kcal, %+.0f%%)\n",
+		summary.BestDay.Date, summary.BestDay.Totals.Calories, summary.BestDay.Deviation*100)
+	fmt.Printf("- Pire jour: %s (%.0f kcal, %+.0f%%)\n",
+		summary.WorstDay.Date, summary.WorstDay.Totals.Calories, summary.WorstDay.Deviation*100)
+}
+
 // Permet de modifier les informations du profil
 func handleProfile(scanner *bufio.Reader) {
 	fmt.Println("\nModification du profil:")
@@ -788,56 +1832,257 @@ func handleProfile(scanner *bufio.Reader) {
 	fmt.Println("Profil mis à jour avec succès!")
 }
 
-// Exporte les données de l'utilisateur en CSV
-func handleExport() {
-	// Créer le dossier d'export s'il n'existe pas
-	exportDir := "exports"
-	if _, err := os.Stat(exportDir); os.IsNotExist(err) {
-		os.Mkdir(exportDir, 0755)
+// Exporte les données de l'utilisateur en CSV, JSON ou Markdown
+func handleExport(args []string) {
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, -1, 0) // 1 mois en arrière par défaut
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	fromStr := flags.String("from", startDate.Format("2006-01-02"), "date de début (AAAA-MM-JJ)")
+	toStr := flags.String("to", endDate.Format("2006-01-02"), "date de fin (AAAA-MM-JJ)")
+	formatStr := flags.String("format", "csv", "format du fichier (csv, json, md)")
+	output := flags.String("out", "", "chemin du fichier (défaut: exports/export_<id>_<date>.<format>)")
+	if err := flags.Parse(args); err != nil {
+		return
 	}
-	
-	// Créer le fichier CSV
-	filename := fmt.Sprintf("%s/export_%d_%s.csv", exportDir, currentUser.ID, time.Now().Format("20060102"))
+
+	from, err := time.ParseInLocation("2006-01-02", *fromStr, time.Local)
+	if err != nil {
+		fmt.Println("Date de début invalide (format attendu: AAAA-MM-JJ)")
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", *toStr, time.Local)
+	if err != nil {
+		fmt.Println("Date de fin invalide (format attendu: AAAA-MM-JJ)")
+		return
+	}
+	if from.After(to) {
+		fmt.Println("La date de début doit précéder la date de fin")
+		return
+	}
+
+	format, err := export.ParseFormat(*formatStr)
+	if err != nil {
+		fmt.Printf("Erreur: %v\n", err)
+		return
+	}
+
+	meals, err := db.GetMealsBetweenDates(currentUser.ID, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		fmt.Printf("Erreur lors de la récupération des repas: %v\n", err)
+		return
+	}
+
+	filename := *output
+	if filename == "" {
+		// Créer le dossier d'export s'il n'existe pas
+		exportDir := "exports"
+		if _, err := os.Stat(exportDir); os.IsNotExist(err) {
+			os.Mkdir(exportDir, 0755)
+		}
+		filename = fmt.Sprintf("%s/export_%d_%s.%s", exportDir, currentUser.ID, time.Now().Format("20060102"), format.Extension())
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		fmt.Printf("Erreur lors de la création du fichier: %v\n", err)
 		return
 	}
 	defer file.Close()
-	
-	// Préparer le writer CSV
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-	
-	// Écrire l'en-tête
-	header := []string{"Date", "Type de repas", "Aliment", "Quantité (g)", "Calories", "Protéines", "Glucides", "Lipides", "Fibres"}
-	writer.Write(header)
-	
-	// Récupérer toutes les données de repas
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, -1, 0) // 1 mois en arrière
-	
-	meals, err := db.GetMealsBetweenDates(currentUser.ID, startDate, endDate)
+
+	if err := export.Write(file, format, meals); err != nil {
+		fmt.Printf("Erreur lors de l'écriture du fichier: %v\n", err)
+		return
+	}
+
+	fmt.Printf("%d repas exportés avec succès dans le fichier: %s\n", len(meals), filename)
+}
+
+// Importe des repas depuis un export CSV de Macro-Tracker, MyFitnessPal ou Cronometer
+func handleImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "valider le fichier sans rien enregistrer")
+	sourceStr := flags.String("source", "csv", "format du fichier (csv, mfp, cronometer)")
+	if err := flags.Parse(args); err != nil {
+		return
+	}
+	// Autoriser les options après le nom du fichier
+	if flags.NArg() > 1 {
+		filename := flags.Arg(0)
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return
+		}
+		args = append([]string{filename}, flags.Args()...)
+	} else {
+		args = flags.Args()
+	}
+	if len(args) != 1 {
+		fmt.Println("Usage: import <fichier.csv> [--source csv|mfp|cronometer] [--dry-run]")
+		return
+	}
+
+	source, err := importer.ParseSource(*sourceStr)
 	if err != nil {
-		fmt.Printf("Erreur lors de la récupération des repas: %v\n", err)
+		fmt.Printf("Erreur: %v\n", err)
 		return
 	}
-	
-	// Écrire les données
-	for _, meal := range meals {
-		row := []string{
-			meal.MealDate.Format("2006-01-02"),
-			meal.MealType,
-			meal.FoodName,
-			fmt.Sprintf("%.1f", meal.Amount),
-			fmt.Sprintf("%.1f", meal.Calories),
-			fmt.Sprintf("%.1f", meal.Proteins),
-			fmt.Sprintf("%.1f", meal.Carbs),
-			fmt.Sprintf("%.1f", meal.Fats),
-			fmt.Sprintf("%.1f", meal.Fiber),
-		}
-		writer.Write(row)
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Printf("Erreur lors de l'ouverture du fichier: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	result, err := importer.Read(source, file, currentUser.ID)
+	if err != nil {
+		fmt.Printf("Erreur lors de la lecture du fichier: %v\n", err)
+		return
+	}
+
+	fmt.Printf("%d repas valides, %d lignes en erreur\n", len(result.Meals), len(result.Errors))
+	for _, rowErr := range result.Errors {
+		fmt.Printf("- %v\n", rowErr)
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Println("Import annulé: corrigez les lignes en erreur puis relancez la commande.")
+		return
+	}
+	if *dryRun {
+		fmt.Println("Simulation terminée, aucun repas enregistré.")
+		return
+	}
+
+	if err := db.AddMeals(result.Meals); err != nil {
+		fmt.Printf("Erreur lors de l'enregistrement des repas, aucun repas importé: %v\n", err)
+		return
+	}
+
+	fmt.Printf("%d repas importés avec succès\n", len(result.Meals))
+}
+
+// Génère une liste de courses à partir de journées types suivies plusieurs jours
+func handleShopping(client fdc.Provider, args []string) {
+	flags := flag.NewFlagSet("shopping", flag.ContinueOnError)
+	formatStr := flags.String("format", "text", "format de la liste (text, md, json)")
+	output := flags.String("out", "", "fichier de sortie (défaut: affichage)")
+
+	// Les options peuvent suivre les journées types
+	var specs []string
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		specs = append(specs, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(specs) == 0 {
+		fmt.Println("Usage: shopping <id journée type>[:jours] ... [--format text|md|json] [--out fichier]")
+		return
+	}
+
+	format, err := shopping.ParseFormat(*formatStr)
+	if err != nil {
+		fmt.Printf("Erreur: %v\n", err)
+		return
+	}
+
+	var plans []shopping.PlanDays
+	for _, spec := range specs {
+		planID, days, err := shopping.ParsePlanSpec(spec)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			return
+		}
+
+		plan, err := db.GetMealPlan(planID)
+		if err != nil || plan.UserID != currentUser.ID {
+			fmt.Printf("Journée type %d non trouvée\n", planID)
+			return
+		}
+
+		items, err := db.GetMealPlanItems(planID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la récupération des repas de '%s': %v\n", plan.Name, err)
+			return
+		}
+		plans = append(plans, shopping.PlanDays{Items: items, Days: days})
+	}
+
+	// Sans catégories, les aliments sont tous classés dans « Autres »
+	categories, _ := shopping.Categories(context.Background(), client, plans)
+	list := shopping.Build(plans, categories)
+
+	writer := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Printf("Erreur lors de la création du fichier: %v\n", err)
+			return
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := list.Write(writer, format); err != nil {
+		fmt.Printf("Erreur lors de l'écriture de la liste: %v\n", err)
+		return
+	}
+	if *output != "" {
+		fmt.Printf("Liste de courses enregistrée dans le fichier: %s\n", *output)
+	}
+}
+
+// Affiche les statistiques du cache FDC ou le vide (entièrement ou pour un
+// seul aliment)
+func handleCache(client fdc.Provider, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: cache <stats|clear [fdcId]>")
+		return
+	}
+
+	switch args[0] {
+	case "stats":
+		stats, err := db.GetFoodCacheStats()
+		if err != nil {
+			fmt.Printf("Erreur lors de la lecture du cache: %v\n", err)
+			return
+		}
+
+		fmt.Println("\nCache des aliments FDC:")
+		fmt.Printf("- Aliments en cache: %d (%d lectures)\n", stats.Foods, stats.FoodHits)
+		fmt.Printf("- Recherches en cache: %d (%d lectures)\n", stats.Searches, stats.SearchHits)
+		if stats.OldestFetchAt != nil {
+			fmt.Printf("- Plus ancienne entrée: %s\n", stats.OldestFetchAt.Format("2006-01-02 15:04"))
+		}
+		if fdcClient, ok := client.(*fdc.Client); ok {
+			session := fdcClient.CacheStats()
+			fmt.Printf("- Cette session: %d réponses du cache, %d requêtes à l'API\n", session.Hits, session.Misses)
+		}
+
+	case "clear":
+		fdcID := 0
+		if len(args) > 1 {
+			id, err := strconv.Atoi(args[1])
+			if err != nil || id <= 0 {
+				fmt.Println("ID aliment invalide")
+				return
+			}
+			fdcID = id
+		}
+
+		removed, err := db.ClearFoodCache(fdcID)
+		if err != nil {
+			fmt.Printf("Erreur lors de la suppression du cache: %v\n", err)
+			return
+		}
+		fmt.Printf("%d entrée(s) supprimée(s) du cache\n", removed)
+
+	default:
+		fmt.Println("Usage: cache <stats|clear [fdcId]>")
 	}
-	
-	fmt.Printf("Données exportées avec succès dans le fichier: %s\n", filename)
 }
@@ -0,0 +1,67 @@
+// Commande fdcimport : importe dans la base locale les fichiers
+// téléchargeables de FoodData Central (Foundation Foods, SR Legacy), au
+// format JSON ou CSV, pour utiliser l'application sans accès à l'API.
+//
+// Usage :
+//
+//	fdcimport <fichier.json|dossier CSV>...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/frachea/macro-tracker/config"
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdcimport"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: fdcimport <fichier.json|dossier CSV>...")
+		fmt.Println("Fichiers disponibles sur https://fdc.nal.usda.gov/download-datasets.html")
+		os.Exit(1)
+	}
+
+	// os.Exit n'exécute pas les defer : la connexion est fermée par run
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(paths []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("Erreur de configuration: %v", err)
+	}
+
+	db, err := database.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("Erreur de connexion à la base de données: %v", err)
+	}
+	defer db.Close()
+
+	err = db.ApplyMigrations("./internal/database/migrations")
+	if err != nil {
+		return fmt.Errorf("Erreur lors de l'application des migrations: %v", err)
+	}
+
+	for _, path := range paths {
+		fmt.Printf("Lecture de %s...\n", path)
+		foods, err := fdcimport.Read(path)
+		if err != nil {
+			return fmt.Errorf("Erreur lors de la lecture de %s: %v", path, err)
+		}
+
+		skipped, err := db.ImportFoods(foods)
+		if err != nil {
+			return fmt.Errorf("Erreur lors de l'import de %s: %v", path, err)
+		}
+		fmt.Printf("%d aliments importés depuis %s\n", len(foods), path)
+		if skipped > 0 {
+			fmt.Printf("%d portions sans poids en grammes ignorées\n", skipped)
+		}
+	}
+	return nil
+}
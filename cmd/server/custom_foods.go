@@ -0,0 +1,218 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/foodref"
+	"github.com/gin-gonic/gin"
+)
+
+// customFoodRequest décrit un aliment personnalisé, avec ses nutriments pour 100g
+type customFoodRequest struct {
+	Name           string                  `json:"name"`
+	Brand          string                  `json:"brand"`
+	Proteins       float64                 `json:"proteins"`
+	Carbs          float64                 `json:"carbs"`
+	Fats           float64                 `json:"fats"`
+	Calories       float64                 `json:"calories"`
+	Fiber          float64                 `json:"fiber"`
+	Micronutrients database.Micronutrients `json:"micronutrients"`
+}
+
+// validate renvoie le message d'erreur à renvoyer au client, ou une chaîne vide
+func (req *customFoodRequest) validate() string {
+	if req.Name == "" {
+		return "Le nom de l'aliment est obligatoire"
+	}
+	if req.Proteins < 0 || req.Carbs < 0 || req.Fats < 0 || req.Calories < 0 || req.Fiber < 0 {
+		return "Les valeurs nutritionnelles ne peuvent pas être négatives"
+	}
+	for _, value := range req.Micronutrients {
+		if value < 0 {
+			return "Les valeurs nutritionnelles ne peuvent pas être négatives"
+		}
+	}
+	if req.Proteins+req.Carbs+req.Fats+req.Fiber > 100 {
+		return "La somme des nutriments ne peut pas dépasser 100g pour 100g"
+	}
+	return ""
+}
+
+func (req *customFoodRequest) apply(food *database.CustomFood) {
+	food.Name = req.Name
+	food.Brand = req.Brand
+	food.Proteins = req.Proteins
+	food.Carbs = req.Carbs
+	food.Fats = req.Fats
+	food.Calories = req.Calories
+	food.Fiber = req.Fiber
+	food.Micronutrients = req.Micronutrients
+}
+
+// customFoodResponse construit la représentation JSON d'un aliment
+// personnalisé, sous la même forme que les résultats de recherche FDC
+func customFoodResponse(food database.CustomFood) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             food.ID,
+		"user_id":        food.UserID,
+		"ref":            foodref.Ref{Source: foodref.Custom, ID: food.ID}.String(),
+		"source":         foodref.Custom,
+		"description":    food.Name,
+		"brand":          food.Brand,
+		"macros":         macrosResponse(food.Proteins, food.Carbs, food.Fats, food.Calories, food.Fiber),
+		"micronutrients": food.Micronutrients,
+	}
+}
+
+func handleGetCustomFoods(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	foods, err := db.GetCustomFoods(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(foods))
+	for _, food := range foods {
+		result = append(result, customFoodResponse(food))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func handleCreateCustomFood(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	var req customFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if message := req.validate(); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	if _, err := db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	food := &database.CustomFood{UserID: userID}
+	req.apply(food)
+
+	err = db.CreateCustomFood(food)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, customFoodResponse(*food))
+}
+
+func handleGetCustomFood(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	foodID, err := strconv.Atoi(c.Param("foodId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID aliment invalide"})
+		return
+	}
+
+	food, err := db.GetCustomFood(userID, foodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Aliment non trouvé"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customFoodResponse(*food))
+}
+
+func handleUpdateCustomFood(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	foodID, err := strconv.Atoi(c.Param("foodId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID aliment invalide"})
+		return
+	}
+
+	var req customFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if message := req.validate(); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	food, err := db.GetCustomFood(userID, foodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Aliment non trouvé"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	req.apply(food)
+
+	err = db.UpdateCustomFood(food)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customFoodResponse(*food))
+}
+
+func handleDeleteCustomFood(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	foodID, err := strconv.Atoi(c.Param("foodId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID aliment invalide"})
+		return
+	}
+
+	err = db.DeleteCustomFood(userID, foodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Aliment non trouvé"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Aliment supprimé avec succès"})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetFoodCacheStats renvoie le contenu du cache FDC et les accès au
+// cache depuis le démarrage du serveur
+func handleGetFoodCacheStats(c *gin.Context) {
+	stats, err := db.GetFoodCacheStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"cache": stats}
+	// Le fournisseur local n'utilise pas le cache
+	if client, ok := fdcClient.(*fdc.Client); ok {
+		response["session"] = client.CacheStats()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleClearFoodCache vide le cache FDC, ou en retire un seul aliment si
+// le paramètre fdc_id est fourni
+func handleClearFoodCache(c *gin.Context) {
+	fdcID := 0
+	if idStr := c.Query("fdc_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID aliment invalide"})
+			return
+		}
+		fdcID = id
+	}
+
+	removed, err := db.ClearFoodCache(fdcID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/gin-gonic/gin"
+)
+
+// setFDCClient remplace le client FDC du serveur le temps du test
+func setFDCClient(t *testing.T, client fdc.Provider) {
+	t.Helper()
+	previous := fdcClient
+	fdcClient = client
+	t.Cleanup(func() { fdcClient = previous })
+}
+
+func TestHandleGetFoodByBarcode(t *testing.T) {
+	fdcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"foods": [
+				{
+					"fdcId": 2345678,
+					"description": "Almond milk, unsweetened",
+					"dataType": "Branded",
+					"gtinUpc": "041570054165",
+					"brandOwner": "Blue Diamond",
+					"foodNutrients": [
+						{"nutrientId": 1003, "nutrientName": "Protein", "value": 0.42},
+						{"nutrientId": 1005, "nutrientName": "Carbohydrate, by difference", "value": 0.42},
+						{"nutrientId": 1004, "nutrientName": "Total lipid (fat)", "value": 1.04},
+						{"nutrientId": 1008, "nutrientName": "Energy", "value": 13}
+					]
+				}
+			]
+		}`))
+	}))
+	defer fdcServer.Close()
+
+	setFDCClient(t, fdc.NewClientWithBaseURL("test-key", fdcServer.URL))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/food/barcode/:code", handleGetFoodByBarcode)
+
+	tests := []struct {
+		name           string
+		code           string
+		expectedStatus int
+	}{
+		{name: "Produit trouvé", code: "041570054165", expectedStatus: http.StatusOK},
+		{name: "Produit absent", code: "99999999", expectedStatus: http.StatusNotFound},
+		{name: "Code invalide", code: "abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/food/barcode/"+tt.code, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("statut = %d, attendu %d (%s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				FdcID      int                `json:"fdcId"`
+				BrandOwner string             `json:"brandOwner"`
+				Macros     map[string]float64 `json:"macros"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("réponse invalide: %v", err)
+			}
+			if body.FdcID != 2345678 || body.BrandOwner != "Blue Diamond" {
+				t.Errorf("réponse = %+v", body)
+			}
+			for _, key := range []string{"proteins", "carbs", "fats", "calories", "fiber"} {
+				if _, ok := body.Macros[key]; !ok {
+					t.Errorf("macro %q absente de la réponse", key)
+				}
+			}
+			if body.Macros["calories"] != 13 {
+				t.Errorf("calories = %v, attendu 13", body.Macros["calories"])
+			}
+		})
+	}
+}
+
+func TestHandleGetFoodErrors(t *testing.T) {
+	tests := []struct {
+		name               string
+		fdcStatus          int
+		retryAfter         string
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{name: "Aliment inconnu", fdcStatus: http.StatusNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Quota épuisé", fdcStatus: http.StatusTooManyRequests, retryAfter: "3600", expectedStatus: http.StatusServiceUnavailable, expectedRetryAfter: "3600"},
+		{name: "Clé API refusée", fdcStatus: http.StatusForbidden, expectedStatus: http.StatusBadGateway},
+		{name: "Panne de l'API", fdcStatus: http.StatusInternalServerError, expectedStatus: http.StatusBadGateway},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/food/:id", handleGetFood)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.fdcStatus)
+			}))
+			defer fdcServer.Close()
+
+			setFDCClient(t, fdc.NewClientWithOptions("test-key", fdc.Options{BaseURL: fdcServer.URL, MaxRetries: -1}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/food/123456", nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("statut = %d, attendu %d (%s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.expectedRetryAfter {
+				t.Errorf("Retry-After = %q, attendu %q", got, tt.expectedRetryAfter)
+			}
+		})
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected fdc.SearchOptions
+		wantErr  bool
+	}{
+		{name: "Valeurs par défaut", query: "", expected: fdc.SearchOptions{PageSize: defaultSearchPageSize}},
+		{
+			name:  "Options complètes",
+			query: "page=2&page_size=25&data_type=Branded,%20Foundation&brand_owner=Danone&sort_by=description&sort_order=desc",
+			expected: fdc.SearchOptions{
+				PageNumber: 2, PageSize: 25, DataTypes: []string{"Branded", "Foundation"},
+				BrandOwner: "Danone", SortBy: fdc.SortByDescription, SortOrder: "desc",
+			},
+		},
+		{name: "Page invalide", query: "page=0", wantErr: true},
+		{name: "Taille excessive", query: "page_size=500", wantErr: true},
+		{name: "Type inconnu", query: "data_type=Bio", wantErr: true},
+		{name: "Tri inconnu", query: "sort_by=calories", wantErr: true},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/food/search?query=yaourt&"+tt.query, nil)
+
+			opts, err := searchOptions(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("searchOptions() = %v, erreur attendue: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(opts, tt.expected) {
+				t.Errorf("searchOptions() = %+v, attendu %+v", opts, tt.expected)
+			}
+		})
+	}
+}
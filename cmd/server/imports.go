@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/importer"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limite la taille des fichiers importés
+const maxImportSize = 10 << 20
+
+func handleImport(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	source, err := importer.ParseSource(c.PostForm("source"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fichier manquant (champ 'file')"})
+		return
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Fichier trop volumineux"})
+		return
+	}
+
+	if _, err := db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	result, err := importer.Read(source, file, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if result.Meals == nil {
+		result.Meals = []database.Meal{}
+	}
+	if result.Errors == nil {
+		result.Errors = []importer.RowError{}
+	}
+
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Des lignes sont invalides, aucun repas n'a été importé",
+			"errors":   result.Errors,
+			"imported": 0,
+		})
+		return
+	}
+
+	if !dryRun {
+		if err := db.AddMeals(result.Meals); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	status := http.StatusCreated
+	imported := len(result.Meals)
+	if dryRun {
+		status = http.StatusOK
+		imported = 0
+	}
+
+	c.JSON(status, gin.H{
+		"dry_run":  dryRun,
+		"imported": imported,
+		"meals":    result.Meals,
+		"errors":   result.Errors,
+	})
+}
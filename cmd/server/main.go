@@ -1,22 +1,29 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/frachea/macro-tracker/config"
 	"github.com/frachea/macro-tracker/internal/database"
 	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/frachea/macro-tracker/internal/foodref"
+	"github.com/frachea/macro-tracker/internal/report"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 var db *database.DB
-var fdcClient *fdc.Client
+var fdcClient fdc.Provider
 
 func main() {
 	dbHost := getEnv("DB_HOST", "db")
@@ -28,14 +35,28 @@ func main() {
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		dbUser, dbPassword, dbHost, dbPort, dbName)
 
-	var err error
+	// Les paramètres FDC et du cache sont lus comme pour le CLI
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erreur de configuration: %v\n", err)
+	}
+
 	db, err = database.NewDB(connStr)
 	if err != nil {
 		log.Fatalf("Erreur de connexion à la base de données: %v\n", err)
 	}
 
-	fdcApiKey := getEnv("FDC_API_KEY", "DEMO_KEY")
-	fdcClient = fdc.NewClient(fdcApiKey)
+	err = db.ApplyMigrations("./internal/database/migrations")
+	if err != nil {
+		log.Fatalf("Erreur lors de l'application des migrations: %v\n", err)
+	}
+
+	if cfg.FDCProvider == "local" {
+		// Aliments importés des fichiers FDC avec la commande fdcimport
+		fdcClient = database.NewLocalProvider(db)
+	} else {
+		fdcClient = newFDCClient(cfg)
+	}
 
 	r := gin.Default()
 
@@ -56,15 +77,47 @@ func main() {
 		api.POST("/users", handleCreateUser)
 		api.PUT("/users/:id", handleUpdateUser)
 
+		api.GET("/users/:id/meals", handleGetMeals)
+		api.POST("/users/:id/meals", handleAddMeal)
+		api.PUT("/users/:id/meals/:mealId", handleUpdateMeal)
+		api.DELETE("/users/:id/meals/:mealId", handleDeleteMeal)
+		api.GET("/users/:id/reports/daily", handleGetDailyReport)
+		api.GET("/users/:id/reports/summary", handleGetSummary)
+		api.GET("/users/:id/history", handleGetHistory)
+		api.GET("/users/:id/export", handleExport)
+		api.POST("/users/:id/import", handleImport)
+
 		api.GET("/users/:id/meal-plans", handleGetMealPlans)
 		api.POST("/users/:id/meal-plans", handleCreateMealPlan)
+		api.POST("/users/:id/meal-plans/generate", handleGenerateMealPlan)
+		api.PUT("/meal-plans/:planId", handleUpdateMealPlan)
+		api.DELETE("/meal-plans/:planId", handleDeleteMealPlan)
+		api.POST("/meal-plans/:planId/duplicate", handleDuplicateMealPlan)
 		api.POST("/meal-plans/:planId/items", handleAddMealPlanItem)
+		api.POST("/meal-plans/:planId/apply", handleApplyMealPlan)
 		api.PUT("/meal-plan-items/:itemId", handleUpdateMealPlanItem)
 		api.PUT("/meal-plan-items/:itemId/meal-type", handleUpdateMealPlanItem)
 		api.DELETE("/meal-plan-items/:itemId", handleDeleteMealPlanItem)
+		api.GET("/shopping-list", handleShoppingList)
+
+		api.GET("/users/:id/recipes", handleGetRecipes)
+		api.POST("/users/:id/recipes", handleCreateRecipe)
+		api.GET("/users/:id/recipes/:recipeId", handleGetRecipe)
+		api.PUT("/users/:id/recipes/:recipeId", handleUpdateRecipe)
+		api.DELETE("/users/:id/recipes/:recipeId", handleDeleteRecipe)
+
+		api.GET("/users/:id/custom-foods", handleGetCustomFoods)
+		api.POST("/users/:id/custom-foods", handleCreateCustomFood)
+		api.GET("/users/:id/custom-foods/:foodId", handleGetCustomFood)
+		api.PUT("/users/:id/custom-foods/:foodId", handleUpdateCustomFood)
+		api.DELETE("/users/:id/custom-foods/:foodId", handleDeleteCustomFood)
 
 		api.GET("/food/search", handleSearchFood)
+		api.GET("/food/barcode/:code", handleGetFoodByBarcode)
 		api.GET("/food/:id", handleGetFood)
+
+		api.GET("/fdc/cache/stats", handleGetFoodCacheStats)
+		api.DELETE("/fdc/cache", handleClearFoodCache)
 	}
 
 	log.Println("Starting server on :8080")
@@ -178,6 +231,8 @@ func handleGetMealPlans(c *gin.Context) {
 		return
 	}
 
+	targets := userTargets(userID)
+
 	result := make([]map[string]interface{}, 0, len(plans))
 	for _, plan := range plans {
 		items, err := db.GetMealPlanItems(plan.ID)
@@ -186,14 +241,7 @@ func handleGetMealPlans(c *gin.Context) {
 			items = []database.MealPlanItem{}
 		}
 
-		planMap := map[string]interface{}{
-			"id":          plan.ID,
-			"user_id":     plan.UserID,
-			"name":        plan.Name,
-			"description": plan.Description,
-			"items":       items,
-		}
-		result = append(result, planMap)
+		result = append(result, mealPlanResponse(plan, items, targets))
 	}
 
 	c.JSON(http.StatusOK, result)
@@ -229,15 +277,164 @@ func handleCreateMealPlan(c *gin.Context) {
 		return
 	}
 
-	response := map[string]interface{}{
+	c.JSON(http.StatusCreated, mealPlanResponse(*plan, []database.MealPlanItem{}, userTargets(userID)))
+}
+
+// mealPlanResponse construit la représentation JSON d'une journée type, de ses
+// éléments et de ses totaux comparés aux objectifs de son propriétaire
+func mealPlanResponse(plan database.MealPlan, items []database.MealPlanItem, targets report.Targets) map[string]interface{} {
+	return map[string]interface{}{
 		"id":          plan.ID,
 		"user_id":     plan.UserID,
 		"name":        plan.Name,
 		"description": plan.Description,
-		"items":       []database.MealPlanItem{},
+		"items":       items,
+		"summary":     report.SummarizePlan(items, targets),
+	}
+}
+
+// userTargets renvoie les objectifs d'un utilisateur, ou des objectifs nuls
+// s'il est introuvable ou si ses objectifs sont illisibles
+func userTargets(userID int) report.Targets {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération de l'utilisateur %d: %v", userID, err)
+		return report.Targets{}
+	}
+	targets, err := report.ParseTargets(user.TargetMacros)
+	if err != nil {
+		log.Printf("Objectifs invalides pour l'utilisateur %d: %v", userID, err)
+	}
+	return targets
+}
+
+func handleUpdateMealPlan(c *gin.Context) {
+	planIDStr := c.Param("planId")
+	planID, err := strconv.Atoi(planIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan invalide"})
+		return
+	}
+
+	var planData struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&planData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := db.GetMealPlan(planID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if planData.Name != nil {
+		if *planData.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le nom ne peut pas être vide"})
+			return
+		}
+		plan.Name = *planData.Name
+	}
+	if planData.Description != nil {
+		plan.Description = *planData.Description
+	}
+
+	err = db.UpdateMealPlan(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := db.GetMealPlanItems(plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []database.MealPlanItem{}
+	}
+
+	c.JSON(http.StatusOK, mealPlanResponse(*plan, items, userTargets(plan.UserID)))
+}
+
+func handleDeleteMealPlan(c *gin.Context) {
+	planIDStr := c.Param("planId")
+	planID, err := strconv.Atoi(planIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan invalide"})
+		return
 	}
 
-	c.JSON(http.StatusCreated, response)
+	err = db.DeleteMealPlan(planID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Journée type supprimée avec succès"})
+}
+
+func handleDuplicateMealPlan(c *gin.Context) {
+	planIDStr := c.Param("planId")
+	planID, err := strconv.Atoi(planIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan invalide"})
+		return
+	}
+
+	var planData struct {
+		Name string `json:"name"`
+	}
+	// Le corps est optionnel : sans nom, la copie reprend celui de l'original
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&planData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	original, err := db.GetMealPlan(planID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := planData.Name
+	if name == "" {
+		name = original.Name + " (copie)"
+	}
+
+	plan, err := db.DuplicateMealPlan(planID, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := db.GetMealPlanItems(plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []database.MealPlanItem{}
+	}
+
+	c.JSON(http.StatusCreated, mealPlanResponse(*plan, items, userTargets(plan.UserID)))
 }
 
 func handleAddMealPlanItem(c *gin.Context) {
@@ -249,15 +446,16 @@ func handleAddMealPlanItem(c *gin.Context) {
 	}
 
 	type ItemRequest struct {
-		MealType string  `json:"meal_type"`
-		FoodID   int     `json:"food_id"`
-		FoodName string  `json:"food_name"`
-		Amount   float64 `json:"amount"`
-		Proteins float64 `json:"proteins"`
-		Carbs    float64 `json:"carbs"`
-		Fats     float64 `json:"fats"`
-		Calories float64 `json:"calories"`
-		Fiber    float64 `json:"fiber"`
+		MealType       string                  `json:"meal_type"`
+		FoodID         int                     `json:"food_id"`
+		FoodName       string                  `json:"food_name"`
+		Amount         float64                 `json:"amount"`
+		Proteins       float64                 `json:"proteins"`
+		Carbs          float64                 `json:"carbs"`
+		Fats           float64                 `json:"fats"`
+		Calories       float64                 `json:"calories"`
+		Fiber          float64                 `json:"fiber"`
+		Micronutrients database.Micronutrients `json:"micronutrients"`
 	}
 
 	var itemReq ItemRequest
@@ -279,6 +477,8 @@ func handleAddMealPlanItem(c *gin.Context) {
 		Fats:       itemReq.Fats,
 		Calories:   itemReq.Calories,
 		Fiber:      itemReq.Fiber,
+
+		Micronutrients: itemReq.Micronutrients,
 	}
 
 	err = db.AddMealPlanItem(&item)
@@ -340,6 +540,76 @@ func handleDeleteMealPlanItem(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Élément supprimé avec succès"})
 }
 
+func handleApplyMealPlan(c *gin.Context) {
+	planIDStr := c.Param("planId")
+	planID, err := strconv.Atoi(planIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan invalide"})
+		return
+	}
+
+	var applyReq struct {
+		UserID int     `json:"user_id"`
+		Date   string  `json:"date"`
+		Factor float64 `json:"factor"`
+	}
+	if err := c.ShouldBindJSON(&applyReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := time.Now()
+	if applyReq.Date != "" {
+		date, err = time.ParseInLocation(dateLayout, applyReq.Date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date invalide (format attendu: AAAA-MM-JJ)"})
+			return
+		}
+	}
+
+	factor := applyReq.Factor
+	if factor == 0 {
+		factor = 1
+	}
+	if factor < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Le facteur doit être positif"})
+		return
+	}
+
+	plan, err := db.GetMealPlan(planID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Une journée type ne peut être appliquée qu'au journal de son propriétaire
+	if applyReq.UserID != 0 && applyReq.UserID != plan.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+		return
+	}
+	userID := plan.UserID
+	if _, err := db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	meals, err := db.ApplyMealPlan(planID, userID, date, factor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, meals)
+}
+
+// defaultSearchPageSize est le nombre d'aliments FDC renvoyés par page de
+// recherche si la requête ne le précise pas
+const defaultSearchPageSize = 10
+
 func handleSearchFood(c *gin.Context) {
 	query := c.Query("query")
 	if query == "" {
@@ -347,33 +617,72 @@ func handleSearchFood(c *gin.Context) {
 		return
 	}
 
-	result, err := fdcClient.SearchFoods(query)
+	opts, err := searchOptions(c)
 	if err != nil {
-		log.Printf("Erreur lors de la recherche FDC pour '%s': %v", query, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if len(result.Foods) == 0 {
-		c.JSON(http.StatusOK, []interface{}{})
+	userID := 0
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		if userID, err = strconv.Atoi(userIDStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+			return
+		}
+	}
+
+	// Les aliments personnalisés de l'utilisateur, sans type FDC ni
+	// fabricant, sont renvoyés en tête de la première page d'une recherche
+	// sans filtre ; le champ "ref" indique la source de chaque aliment
+	processedFoods := []map[string]interface{}{}
+	if userID != 0 && opts.Page() == 1 && len(opts.DataTypes) == 0 && opts.BrandOwner == "" {
+		customFoods, err := db.SearchCustomFoods(userID, query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, food := range customFoods {
+			processedFoods = append(processedFoods, customFoodResponse(food))
+		}
+	}
+
+	ctx := c.Request.Context()
+	result, err := db.SearchFoods(ctx, fdcClient, userID, query, opts)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche FDC pour '%s': %v", query, err)
+		// Les erreurs de la base de données ne sont pas détaillées au client
+		if !errors.Is(err, database.ErrRemoteSearch) && !errors.Is(err, fdc.ErrInvalidSearch) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la recherche d'aliments"})
+			return
+		}
+		respondFDCError(c, err)
 		return
 	}
 
-	// Vérifier que les résultats ont bien des nutriments
-	processedFoods := make([]map[string]interface{}, 0, len(result.Foods))
-	for i, food := range result.Foods {
-		// Limiter à 10 résultats pour des performances optimales
-		if i >= 10 {
-			break
+	// Les détails des résultats sans nutriments sont demandés en une fois
+	var missing []int
+	for _, food := range result.Foods {
+		if len(food.Nutrients) == 0 {
+			missing = append(missing, food.FdcID)
 		}
-		
+	}
+	details := make(map[int]*fdc.Food, len(missing))
+	if len(missing) > 0 {
+		foods, err := fdcClient.GetFoodsContext(ctx, missing)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des détails FDC: %v", err)
+		}
+		for i := range foods {
+			details[foods[i].FdcID] = &foods[i]
+		}
+	}
+
+	// Vérifier que les résultats ont bien des nutriments
+	for _, food := range result.Foods {
 		// Ajouter des informations nutritionnelles si absentes
 		detailedFood := &food
-		if len(food.Nutrients) == 0 {
-			tmpFood, err := fdcClient.GetFood(food.FdcID)
-			if err == nil && tmpFood != nil {
-				detailedFood = tmpFood
-			}
+		if detail, ok := details[food.FdcID]; ok {
+			detailedFood = detail
 		}
 		
 		// Calculer les macros pour chaque aliment
@@ -389,6 +698,8 @@ func handleSearchFood(c *gin.Context) {
 		// Créer un objet avec les informations nécessaires
 		processedFood := map[string]interface{}{
 			"fdcId":       detailedFood.FdcID,
+			"ref":         foodref.Ref{Source: foodref.FDC, ID: detailedFood.FdcID}.String(),
+			"source":      foodref.FDC,
 			"description": detailedFood.Description,
 			"dataType":    detailedFood.DataType,
 			"nutrients":   detailedFood.Nutrients,
@@ -407,7 +718,47 @@ func handleSearchFood(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, processedFoods)
+	c.JSON(http.StatusOK, gin.H{
+		"foods":       processedFoods,
+		"totalHits":   result.TotalHits,
+		"currentPage": opts.Page(),
+		"totalPages":  result.TotalPages,
+		"pageSize":    opts.Size(),
+	})
+}
+
+// searchOptions lit la pagination, les filtres et le tri d'une recherche :
+// page, page_size, data_type (liste séparée par des virgules), brand_owner,
+// sort_by et sort_order
+func searchOptions(c *gin.Context) (fdc.SearchOptions, error) {
+	opts := fdc.SearchOptions{
+		PageSize:   defaultSearchPageSize,
+		BrandOwner: c.Query("brand_owner"),
+		SortOrder:  c.Query("sort_order"),
+	}
+
+	var err error
+	if page := c.Query("page"); page != "" {
+		if opts.PageNumber, err = strconv.Atoi(page); err != nil || opts.PageNumber < 1 {
+			return opts, fmt.Errorf("numéro de page invalide: %q", page)
+		}
+	}
+	if size := c.Query("page_size"); size != "" {
+		if opts.PageSize, err = strconv.Atoi(size); err != nil || opts.PageSize < 1 {
+			return opts, fmt.Errorf("taille de page invalide: %q", size)
+		}
+	}
+	if dataTypes := c.Query("data_type"); dataTypes != "" {
+		for _, dataType := range strings.Split(dataTypes, ",") {
+			opts.DataTypes = append(opts.DataTypes, strings.TrimSpace(dataType))
+		}
+	}
+	if sortBy := c.Query("sort_by"); sortBy != "" {
+		if opts.SortBy, err = fdc.ParseSort(sortBy); err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
 }
 
 func handleGetFood(c *gin.Context) {
@@ -418,36 +769,100 @@ func handleGetFood(c *gin.Context) {
 		return
 	}
 
-	food, err := fdcClient.GetFood(id)
+	food, err := fdcClient.GetFoodContext(c.Request.Context(), id)
 	if err != nil {
 		log.Printf("Erreur lors de la récupération de l'aliment %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondFDCError(c, err)
 		return
 	}
 
-	// Calculer les macros
+	c.JSON(http.StatusOK, foodResponse(food))
+}
+
+// foodResponse construit la représentation JSON d'un aliment FDC avec ses
+// macros pour 100g, pour simplifier l'utilisation côté client
+func foodResponse(food *fdc.Food) map[string]interface{} {
 	proteins, carbs, fats, calories, fiber := food.GetMacros()
-	
-	// Ajouter les macros aux détails de l'aliment pour simplifier l'utilisation côté client
-	response := map[string]interface{}{
-		"fdcId":       food.FdcID,
-		"description": food.Description,
-		"nutrients":   food.Nutrients,
-		"macros": map[string]float64{
-			"proteins": proteins,
-			"carbs":    carbs,
-			"fats":     fats, 
-			"calories": calories,
-			"fiber":    fiber,
-		},
-	}
-	
+
 	log.Printf("Détail aliment: %s, Protéines: %.2f, Glucides: %.2f, Lipides: %.2f, Calories: %.2f, Fibres: %.2f",
 		food.Description, proteins, carbs, fats, calories, fiber)
 
+	return map[string]interface{}{
+		"fdcId":          food.FdcID,
+		"description":    food.Description,
+		"nutrients":      food.Nutrients,
+		"macros":         macrosResponse(proteins, carbs, fats, calories, fiber),
+		"micronutrients": food.MicronutrientsFor(100),
+		"portions":       food.HouseholdPortions(),
+	}
+}
+
+func handleGetFoodByBarcode(c *gin.Context) {
+	code := c.Param("code")
+	if !fdc.ValidBarcode(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code-barres invalide (8 à 14 chiffres attendus)"})
+		return
+	}
+
+	food, err := fdcClient.GetFoodByBarcodeContext(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, fdc.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Aucun produit ne correspond à ce code-barres"})
+			return
+		}
+		log.Printf("Erreur lors de la recherche du code-barres %s: %v", code, err)
+		respondFDCError(c, err)
+		return
+	}
+
+	response := foodResponse(food)
+	response["gtinUpc"] = food.GtinUpc
+	response["brandOwner"] = food.BrandOwner
 	c.JSON(http.StatusOK, response)
 }
 
+// fdcErrorStatus associe une erreur de l'API FDC au statut HTTP renvoyé au
+// client et au message à afficher
+func fdcErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, fdc.ErrNotFound):
+		return http.StatusNotFound, "Aliment introuvable dans FoodData Central"
+	case errors.Is(err, fdc.ErrInvalidSearch):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, fdc.ErrRateLimited):
+		return http.StatusServiceUnavailable, "Limite de requêtes FoodData Central atteinte, réessayez plus tard"
+	case errors.Is(err, fdc.ErrUnauthorized):
+		return http.StatusBadGateway, "Clé API FoodData Central refusée"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "FoodData Central ne répond pas"
+	default:
+		// Les erreurs réseau reprennent l'URL de la requête : elles ne sont
+		// pas renvoyées au client
+		return http.StatusBadGateway, "Service FoodData Central indisponible"
+	}
+}
+
+// respondFDCError renvoie l'erreur FDC au client, avec l'en-tête
+// Retry-After si l'API a indiqué un délai
+func respondFDCError(c *gin.Context, err error) {
+	var apiErr *fdc.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+	}
+	status, message := fdcErrorStatus(err)
+	c.JSON(status, gin.H{"error": message})
+}
+
+// newFDCClient crée le client de l'API FDC, avec le cache en base de données
+func newFDCClient(cfg *config.Config) *fdc.Client {
+	return fdc.NewClientWithOptions(cfg.FDCApiKey, fdc.Options{
+		BaseURL:   cfg.FDCBaseURL,
+		Timeout:   cfg.FDCTimeout,
+		Cache:     db,
+		SearchTTL: cfg.FDCSearchTTL,
+	})
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/frachea/macro-tracker/internal/foodref"
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseDateTime accepte une date seule (2006-01-02) ou un horodatage RFC3339
+func parseDateTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(dateLayout, value, time.Local)
+}
+
+// parseDateQuery lit un paramètre de date optionnel, avec une valeur par défaut
+func parseDateQuery(c *gin.Context, key string, defaultValue time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseInLocation(dateLayout, value, time.Local)
+}
+
+// endOfDay renvoie le dernier instant de la journée de t
+func endOfDay(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+func handleGetMeals(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	var meals []database.Meal
+	if c.Query("from") != "" || c.Query("to") != "" {
+		to, err := parseDateQuery(c, "to", time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date de fin invalide (format attendu: AAAA-MM-JJ)"})
+			return
+		}
+		from, err := parseDateQuery(c, "from", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date de début invalide (format attendu: AAAA-MM-JJ)"})
+			return
+		}
+		if from.After(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La date de début doit précéder la date de fin"})
+			return
+		}
+		meals, err = db.GetMealsBetweenDates(userID, from, endOfDay(to))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		date, err := parseDateQuery(c, "date", time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date invalide (format attendu: AAAA-MM-JJ)"})
+			return
+		}
+		meals, err = db.GetDailyMeals(userID, date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if meals == nil {
+		meals = []database.Meal{}
+	}
+
+	c.JSON(http.StatusOK, meals)
+}
+
+func handleAddMeal(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	// Un repas porte sur un aliment FDC, un aliment personnalisé ou une
+	// recette, désigné par son ID ou par sa référence (ex: "perso:12").
+	// La quantité est donnée en grammes (amount), avec une unité (amount et
+	// unit) ou sous forme de texte (quantity, ex: "2 slice", "1.5 cup") ; une
+	// recette peut aussi être enregistrée en nombre de portions
+	var mealReq struct {
+		FoodID       int     `json:"food_id"`
+		CustomFoodID int     `json:"custom_food_id"`
+		RecipeID     int     `json:"recipe_id"`
+		FoodRef      string  `json:"food_ref"`
+		Amount       float64 `json:"amount"`
+		Unit         string  `json:"unit"`
+		Quantity     string  `json:"quantity"`
+		Servings     float64 `json:"servings"`
+		MealType     string  `json:"meal_type"`
+		MealDate     string  `json:"meal_date"`
+	}
+	if err := c.ShouldBindJSON(&mealReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mealReq.Quantity != "" {
+		if mealReq.Amount != 0 || mealReq.Unit != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Indiquez soit quantity, soit amount et unit"})
+			return
+		}
+		mealReq.Amount, mealReq.Unit, err = fdc.ParseQuantity(mealReq.Quantity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if mealReq.FoodRef != "" {
+		ref, err := foodref.Parse(mealReq.FoodRef)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		switch ref.Source {
+		case foodref.FDC:
+			mealReq.FoodID = ref.ID
+		case foodref.Custom:
+			mealReq.CustomFoodID = ref.ID
+		case foodref.Recipe:
+			mealReq.RecipeID = ref.ID
+		}
+	}
+
+	sources := 0
+	for _, id := range []int{mealReq.FoodID, mealReq.CustomFoodID, mealReq.RecipeID} {
+		if id > 0 {
+			sources++
+		}
+	}
+	if sources != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Indiquez un seul aliment : ID aliment, ID d'aliment personnalisé ou ID de recette"})
+		return
+	}
+	if mealReq.Servings < 0 || (mealReq.Servings > 0 && mealReq.RecipeID == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Le nombre de portions n'est accepté que pour une recette et doit être positif"})
+		return
+	}
+	if mealReq.Amount <= 0 && mealReq.Servings == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La quantité doit être positive"})
+		return
+	}
+	if !database.MealType(mealReq.MealType).IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type de repas invalide"})
+		return
+	}
+
+	mealDate := time.Now()
+	if mealReq.MealDate != "" {
+		mealDate, err = parseDateTime(mealReq.MealDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date de repas invalide"})
+			return
+		}
+	}
+
+	if _, err := db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	var meal database.Meal
+	switch {
+	case mealReq.CustomFoodID > 0:
+		food, err := db.GetCustomFood(userID, mealReq.CustomFoodID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Aliment non trouvé"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		grams, ok := fdc.MassUnitGrams(mealReq.Unit)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unité invalide pour un aliment personnalisé (g, kg, oz ou lb)"})
+			return
+		}
+		meal = food.NewMeal(userID, mealReq.MealType, mealDate, mealReq.Amount*grams)
+
+	case mealReq.RecipeID > 0:
+		recipe, err := db.GetRecipe(userID, mealReq.RecipeID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Recette non trouvée"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		amount := mealReq.Amount
+		if mealReq.Servings > 0 {
+			amount = mealReq.Servings * recipe.ServingWeight()
+		} else if database.IsServingUnit(mealReq.Unit) {
+			amount *= recipe.ServingWeight()
+		} else if grams, ok := fdc.MassUnitGrams(mealReq.Unit); ok {
+			amount *= grams
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unité invalide pour une recette (g ou portion)"})
+			return
+		}
+		meal = recipe.NewMeal(userID, mealReq.MealType, mealDate, amount)
+
+	default:
+		food, err := fdcClient.GetFoodContext(c.Request.Context(), mealReq.FoodID)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération de l'aliment %d: %v", mealReq.FoodID, err)
+			respondFDCError(c, err)
+			return
+		}
+
+		// Convertir les unités ménagères (slice, cup...) en grammes
+		amount, err := food.GramsFor(mealReq.Amount, mealReq.Unit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		proteins, carbs, fats, calories, fiber := food.MacrosFor(amount)
+
+		meal = database.Meal{
+			UserID:         userID,
+			MealType:       mealReq.MealType,
+			MealDate:       mealDate,
+			FoodID:         food.FdcID,
+			FoodName:       food.Description,
+			Amount:         amount,
+			Proteins:       proteins,
+			Carbs:          carbs,
+			Fats:           fats,
+			Calories:       calories,
+			Fiber:          fiber,
+			Micronutrients: food.MicronutrientsFor(amount),
+		}
+	}
+
+	err = db.AddMeal(&meal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, meal)
+}
+
+func handleUpdateMeal(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	mealID, err := strconv.Atoi(c.Param("mealId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de repas invalide"})
+		return
+	}
+
+	var mealReq struct {
+		Amount   *float64 `json:"amount"`
+		MealType *string  `json:"meal_type"`
+		MealDate *string  `json:"meal_date"`
+	}
+	if err := c.ShouldBindJSON(&mealReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meal, err := db.GetMeal(userID, mealID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repas non trouvé"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mealReq.Amount != nil {
+		if *mealReq.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La quantité doit être positive"})
+			return
+		}
+		meal.ScaleTo(*mealReq.Amount)
+	}
+	if mealReq.MealType != nil {
+		if !database.MealType(*mealReq.MealType).IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Type de repas invalide"})
+			return
+		}
+		meal.MealType = *mealReq.MealType
+	}
+	if mealReq.MealDate != nil {
+		meal.MealDate, err = parseDateTime(*mealReq.MealDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date de repas invalide"})
+			return
+		}
+	}
+
+	err = db.UpdateMeal(meal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, meal)
+}
+
+func handleDeleteMeal(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	mealID, err := strconv.Atoi(c.Param("mealId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de repas invalide"})
+		return
+	}
+
+	err = db.DeleteMeal(userID, mealID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repas non trouvé"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Repas supprimé avec succès"})
+}
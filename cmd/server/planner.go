@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/planner"
+	"github.com/frachea/macro-tracker/internal/report"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultFoodsPerMeal est le nombre d'aliments de l'historique retenus par repas
+	defaultFoodsPerMeal = 3
+	// historyFoodsLimit est le nombre d'aliments fréquents lus dans l'historique
+	historyFoodsLimit = 100
+)
+
+func handleGenerateMealPlan(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	var genReq struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Source      string `json:"source"`
+		PerMeal     int    `json:"per_meal"`
+		Foods       []struct {
+			FdcID    int               `json:"fdc_id"`
+			MealType database.MealType `json:"meal_type"`
+			MinGrams float64           `json:"min_grams"`
+			MaxGrams float64           `json:"max_grams"`
+		} `json:"foods"`
+		Meals []planner.MealConstraint `json:"meals"`
+	}
+	if err := c.ShouldBindJSON(&genReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if genReq.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nom de la journée type manquant"})
+		return
+	}
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+	targets, err := report.ParseTargets(user.TargetMacros)
+	if err != nil || !targets.IsSet() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Aucun objectif nutritionnel défini pour cet utilisateur"})
+		return
+	}
+
+	source := genReq.Source
+	if source == "" {
+		source = "history"
+		if len(genReq.Foods) > 0 {
+			source = "foods"
+		}
+	}
+
+	var candidates []planner.Candidate
+	switch source {
+	case "history":
+		perMeal := genReq.PerMeal
+		if perMeal <= 0 {
+			perMeal = defaultFoodsPerMeal
+		}
+		foods, err := db.GetFrequentFoods(userID, historyFoodsLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		candidates = planner.FromHistory(foods, perMeal)
+
+	case "foods":
+		for _, requested := range genReq.Foods {
+			food, err := fdcClient.GetFoodContext(c.Request.Context(), requested.FdcID)
+			if err != nil {
+				log.Printf("Erreur lors de la récupération de l'aliment %d: %v", requested.FdcID, err)
+				respondFDCError(c, err)
+				return
+			}
+			candidate := planner.FromFood(food, requested.MealType)
+			candidate.MinGrams = requested.MinGrams
+			candidate.MaxGrams = requested.MaxGrams
+			candidates = append(candidates, candidate)
+		}
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source inconnue (attendu: history ou foods)"})
+		return
+	}
+
+	items, err := planner.Generate(targets, candidates, genReq.Meals)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan := &database.MealPlan{
+		UserID:      userID,
+		Name:        genReq.Name,
+		Description: genReq.Description,
+	}
+	if err := db.CreateMealPlanWithItems(plan, items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, mealPlanResponse(*plan, items, targets))
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/gin-gonic/gin"
+)
+
+// recipeRequest décrit une recette envoyée par le client : seuls les
+// identifiants FDC et les quantités des ingrédients sont fournis, les
+// nutriments sont récupérés auprès de l'API FDC
+type recipeRequest struct {
+	Name         string  `json:"name"`
+	CookedWeight float64 `json:"cooked_weight"`
+	Servings     float64 `json:"servings"`
+	Ingredients  []struct {
+		FoodID int     `json:"food_id"`
+		Amount float64 `json:"amount"`
+	} `json:"ingredients"`
+}
+
+// toRecipe valide la requête et construit la recette, ou renvoie le statut
+// HTTP et le message d'erreur à renvoyer au client
+func (req *recipeRequest) toRecipe(ctx context.Context) (*database.Recipe, int, string) {
+	if req.Name == "" {
+		return nil, http.StatusBadRequest, "Le nom de la recette est obligatoire"
+	}
+	if len(req.Ingredients) == 0 {
+		return nil, http.StatusBadRequest, "La recette doit contenir au moins un ingrédient"
+	}
+	if req.CookedWeight < 0 {
+		return nil, http.StatusBadRequest, "Le poids après cuisson ne peut pas être négatif"
+	}
+	if req.Servings == 0 {
+		req.Servings = 1
+	}
+	if req.Servings < 0 {
+		return nil, http.StatusBadRequest, "Le nombre de portions doit être positif"
+	}
+
+	recipe := &database.Recipe{
+		Name:         req.Name,
+		CookedWeight: req.CookedWeight,
+		Servings:     req.Servings,
+	}
+	for _, ingredient := range req.Ingredients {
+		if ingredient.FoodID <= 0 {
+			return nil, http.StatusBadRequest, "ID aliment invalide"
+		}
+		if ingredient.Amount <= 0 {
+			return nil, http.StatusBadRequest, "La quantité de chaque ingrédient doit être positive"
+		}
+
+		food, err := fdcClient.GetFoodContext(ctx, ingredient.FoodID)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération de l'aliment %d: %v", ingredient.FoodID, err)
+			status, message := fdcErrorStatus(err)
+			return nil, status, message
+		}
+
+		proteins, carbs, fats, calories, fiber := food.MacrosFor(ingredient.Amount)
+		recipe.Ingredients = append(recipe.Ingredients, database.RecipeIngredient{
+			FoodID:         food.FdcID,
+			FoodName:       food.Description,
+			Amount:         ingredient.Amount,
+			Proteins:       proteins,
+			Carbs:          carbs,
+			Fats:           fats,
+			Calories:       calories,
+			Fiber:          fiber,
+			Micronutrients: food.MicronutrientsFor(ingredient.Amount),
+		})
+	}
+	return recipe, 0, ""
+}
+
+// macrosResponse regroupe des valeurs nutritionnelles sous la même forme que
+// les macros des aliments FDC
+func macrosResponse(proteins, carbs, fats, calories, fiber float64) map[string]float64 {
+	return map[string]float64{
+		"proteins": proteins,
+		"carbs":    carbs,
+		"fats":     fats,
+		"calories": calories,
+		"fiber":    fiber,
+	}
+}
+
+// recipeResponse construit la représentation JSON d'une recette avec ses
+// nutriments pour 100g et par portion
+func recipeResponse(recipe database.Recipe) map[string]interface{} {
+	ingredients := recipe.Ingredients
+	if ingredients == nil {
+		ingredients = []database.RecipeIngredient{}
+	}
+	return map[string]interface{}{
+		"id":             recipe.ID,
+		"user_id":        recipe.UserID,
+		"name":           recipe.Name,
+		"cooked_weight":  recipe.CookedWeight,
+		"servings":       recipe.Servings,
+		"ingredients":    ingredients,
+		"weight":         recipe.Weight(),
+		"serving_weight": recipe.ServingWeight(),
+		"macros":         macrosResponse(recipe.MacrosFor(100)),
+		"serving_macros": macrosResponse(recipe.MacrosFor(recipe.ServingWeight())),
+	}
+}
+
+func handleGetRecipes(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	recipes, err := db.GetRecipes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(recipes))
+	for _, recipe := range recipes {
+		result = append(result, recipeResponse(recipe))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func handleCreateRecipe(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	var req recipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	recipe, status, message := req.toRecipe(c.Request.Context())
+	if recipe == nil {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	recipe.UserID = userID
+
+	err = db.CreateRecipe(recipe)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recipeResponse(*recipe))
+}
+
+func handleGetRecipe(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	recipeID, err := strconv.Atoi(c.Param("recipeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de recette invalide"})
+		return
+	}
+
+	recipe, err := db.GetRecipe(userID, recipeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recette non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recipeResponse(*recipe))
+}
+
+func handleUpdateRecipe(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	recipeID, err := strconv.Atoi(c.Param("recipeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de recette invalide"})
+		return
+	}
+
+	var req recipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, err := db.GetRecipe(userID, recipeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recette non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, status, message := req.toRecipe(c.Request.Context())
+	if recipe == nil {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	recipe.ID = existing.ID
+	recipe.UserID = existing.UserID
+
+	err = db.UpdateRecipe(recipe)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recette non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recipeResponse(*recipe))
+}
+
+func handleDeleteRecipe(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	recipeID, err := strconv.Atoi(c.Param("recipeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de recette invalide"})
+		return
+	}
+
+	err = db.DeleteRecipe(userID, recipeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recette non trouvée"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recette supprimée avec succès"})
+}
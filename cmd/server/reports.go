@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/export"
+	"github.com/frachea/macro-tracker/internal/report"
+	"github.com/gin-gonic/gin"
+)
+
+func handleGetDailyReport(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	date, err := parseDateQuery(c, "date", time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	meals, err := db.GetDailyMeals(userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	targets, err := report.ParseTargets(user.TargetMacros)
+	if err != nil {
+		log.Printf("Objectifs invalides pour l'utilisateur %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, report.Daily(date, meals, targets))
+}
+
+// maxHistoryDays limite la période couverte par une requête d'historique
+const maxHistoryDays = 366
+
+func handleGetHistory(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	to, err := parseDateQuery(c, "to", time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date de fin invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+	from, err := parseDateQuery(c, "from", to.AddDate(0, 0, -6))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date de début invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La date de début doit précéder la date de fin"})
+		return
+	}
+	if to.Sub(from) > maxHistoryDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La période ne peut pas dépasser %d jours", maxHistoryDays)})
+		return
+	}
+
+	days, err := db.GetDailyTotalsBetweenDates(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if days == nil {
+		days = []database.DailyTotals{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format(dateLayout),
+		"to":   to.Format(dateLayout),
+		"days": days,
+	})
+}
+
+func handleGetSummary(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	date, err := parseDateQuery(c, "date", time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+
+	period := report.Period(c.DefaultQuery("period", string(report.Week)))
+	start, end, err := period.Bounds(date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Utilisateur non trouvé"})
+		return
+	}
+
+	meals, err := db.GetMealsBetweenDates(userID, start, endOfDay(end))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	targets, err := report.ParseTargets(user.TargetMacros)
+	if err != nil {
+		log.Printf("Objectifs invalides pour l'utilisateur %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, report.Summarize(start, end, meals, targets))
+}
+
+func handleExport(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	to, err := parseDateQuery(c, "to", time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date de fin invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+	from, err := parseDateQuery(c, "from", to.AddDate(0, -1, 0))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date de début invalide (format attendu: AAAA-MM-JJ)"})
+		return
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La date de début doit précéder la date de fin"})
+		return
+	}
+
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.CSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meals, err := db.GetMealsBetweenDates(userID, from, endOfDay(to))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("export_%d_%s_%s.%s", userID, from.Format("20060102"), to.Format("20060102"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer, format, meals); err != nil {
+		log.Printf("Erreur lors de l'export pour l'utilisateur %d: %v", userID, err)
+	}
+}
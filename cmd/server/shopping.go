@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/shopping"
+	"github.com/gin-gonic/gin"
+)
+
+func handleShoppingList(c *gin.Context) {
+	specs := strings.Split(c.Query("plans"), ",")
+	if c.Query("plans") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Journées types manquantes (plans=id:jours,...)"})
+		return
+	}
+
+	format, err := shopping.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var plans []shopping.PlanDays
+	for _, spec := range specs {
+		planID, days, err := shopping.ParsePlanSpec(spec)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, err := db.GetMealPlan(planID); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Journée type non trouvée"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		items, err := db.GetMealPlanItems(planID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		plans = append(plans, shopping.PlanDays{Items: items, Days: days})
+	}
+
+	categories, err := shopping.Categories(c.Request.Context(), fdcClient, plans)
+	if err != nil {
+		log.Printf("Catégories indisponibles pour la liste de courses: %v", err)
+	}
+	list := shopping.Build(plans, categories)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+	if err := list.Write(c.Writer, format); err != nil {
+		log.Printf("Erreur lors de l'écriture de la liste de courses: %v", err)
+	}
+}
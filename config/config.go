@@ -1,13 +1,19 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"time"
 )
 
 type Config struct {
-	DatabaseURL string
-	ServerPort  string
-	FDCApiKey   string
+	DatabaseURL  string
+	ServerPort   string
+	FDCApiKey    string
+	FDCBaseURL   string
+	FDCTimeout   time.Duration
+	FDCSearchTTL time.Duration
+	FDCProvider  string
 }
 
 func Load() (*Config, error) {
@@ -15,7 +21,27 @@ func Load() (*Config, error) {
 		DatabaseURL: getEnvOrDefault("DATABASE_URL", "postgres://localhost:5432/macro_tracker?sslmode=disable"),
 		ServerPort:  getEnvOrDefault("SERVER_PORT", "8080"),
 		FDCApiKey:   getEnvOrDefault("FDC_API_KEY", "VkIvae2DDaLi0qdVhHgk0vhG216IgfDlqBGgDOwU"),
+		FDCBaseURL:  getEnvOrDefault("FDC_BASE_URL", "https://api.nal.usda.gov/fdc/v1"),
+		FDCProvider: getEnvOrDefault("FDC_PROVIDER", "api"),
 	}
+
+	// "api" interroge FoodData Central, "local" la base importée avec fdcimport
+	if config.FDCProvider != "api" && config.FDCProvider != "local" {
+		return nil, fmt.Errorf("FDC_PROVIDER invalide: %q (api ou local)", config.FDCProvider)
+	}
+
+	timeout, err := time.ParseDuration(getEnvOrDefault("FDC_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("FDC_TIMEOUT invalide: %v", err)
+	}
+	config.FDCTimeout = timeout
+
+	searchTTL, err := time.ParseDuration(getEnvOrDefault("FDC_SEARCH_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("FDC_SEARCH_TTL invalide: %v", err)
+	}
+	config.FDCSearchTTL = searchTTL
+
 	return config, nil
 }
 
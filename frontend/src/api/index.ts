@@ -133,6 +133,15 @@ export const updateMealPlanItemMealType = async (itemId: number, mealType: strin
   return response;
 };
 
+// Page de résultats renvoyée par /food/search
+interface FoodSearchPage {
+  foods: Food[];
+  totalHits: number;
+  currentPage: number;
+  totalPages: number;
+  pageSize: number;
+}
+
 let searchTimeout: number | null = null;
 export const searchFood = async (query: string): Promise<AxiosResponse<Food[]>> => {
   if (!query.trim()) {
@@ -152,8 +161,8 @@ export const searchFood = async (query: string): Promise<AxiosResponse<Food[]>>
   return new Promise((resolve, reject) => {
     searchTimeout = window.setTimeout(async () => {
       try {
-        const response = await api.get<Food[]>(`/food/search?query=${encodeURIComponent(query)}`);
-        resolve(response);
+        const response = await api.get<FoodSearchPage>(`/food/search?query=${encodeURIComponent(query)}`);
+        resolve({ ...response, data: response.data.foods });
       } catch (error) {
         reject(error);
       }
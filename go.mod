@@ -6,6 +6,7 @@ require (
 	github.com/gin-contrib/cors v1.7.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/lib/pq v1.10.9
+	golang.org/x/sync v0.7.0
 )
 
 require (
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CustomFood est un aliment défini par un utilisateur, absent de FoodData
+// Central, avec ses nutriments pour 100g
+type CustomFood struct {
+	ID             int            `json:"id"`
+	UserID         int            `json:"user_id"`
+	Name           string         `json:"name"`
+	Brand          string         `json:"brand"`
+	Proteins       float64        `json:"proteins"`
+	Carbs          float64        `json:"carbs"`
+	Fats           float64        `json:"fats"`
+	Calories       float64        `json:"calories"`
+	Fiber          float64        `json:"fiber"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
+}
+
+// MacrosFor renvoie les nutriments d'une quantité (en grammes) de l'aliment
+func (f *CustomFood) MacrosFor(amount float64) (proteins, carbs, fats, calories, fiber float64) {
+	ratio := amount / 100
+	return f.Proteins * ratio, f.Carbs * ratio, f.Fats * ratio, f.Calories * ratio, f.Fiber * ratio
+}
+
+// NewMeal crée le repas correspondant à une quantité (en grammes) de l'aliment
+func (f *CustomFood) NewMeal(userID int, mealType string, date time.Time, amount float64) Meal {
+	proteins, carbs, fats, calories, fiber := f.MacrosFor(amount)
+	customFoodID := f.ID
+	return Meal{
+		UserID:       userID,
+		MealType:     mealType,
+		MealDate:     date,
+		FoodName:     f.Name,
+		Amount:       amount,
+		Proteins:     proteins,
+		Carbs:        carbs,
+		Fats:         fats,
+		Calories:     calories,
+		Fiber:        fiber,
+		CustomFoodID: &customFoodID,
+
+		Micronutrients: Micronutrients(nil).Add(f.Micronutrients, amount/100),
+	}
+}
+
+func (db *DB) CreateCustomFood(food *CustomFood) error {
+	query := `
+		INSERT INTO custom_foods (user_id, name, brand, proteins, carbs, fats, calories, fiber, micronutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	return db.QueryRow(
+		query,
+		food.UserID,
+		food.Name,
+		food.Brand,
+		food.Proteins,
+		food.Carbs,
+		food.Fats,
+		food.Calories,
+		food.Fiber,
+		food.Micronutrients,
+	).Scan(&food.ID)
+}
+
+// GetCustomFood renvoie un aliment personnalisé de l'utilisateur, ou
+// sql.ErrNoRows s'il appartient à un autre utilisateur
+func (db *DB) GetCustomFood(userID, foodID int) (*CustomFood, error) {
+	food := &CustomFood{}
+	err := db.QueryRow(`
+		SELECT id, user_id, name, brand, proteins, carbs, fats, calories, fiber, micronutrients
+		FROM custom_foods
+		WHERE id = $1 AND user_id = $2
+	`, foodID, userID).Scan(
+		&food.ID, &food.UserID, &food.Name, &food.Brand,
+		&food.Proteins, &food.Carbs, &food.Fats, &food.Calories, &food.Fiber, &food.Micronutrients,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return food, nil
+}
+
+func (db *DB) GetCustomFoods(userID int) ([]CustomFood, error) {
+	return queryCustomFoods(db, `
+		SELECT id, user_id, name, brand, proteins, carbs, fats, calories, fiber, micronutrients
+		FROM custom_foods
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+}
+
+// SearchCustomFoods renvoie les aliments personnalisés d'un utilisateur dont
+// le nom ou la marque correspond à la requête, y compris avec une faute de
+// frappe ou un pluriel. Les aliments les plus consommés sont classés en tête.
+func (db *DB) SearchCustomFoods(userID int, query string) ([]CustomFood, error) {
+	return queryCustomFoods(db, `
+		SELECT cf.id, cf.user_id, cf.name, cf.brand, cf.proteins, cf.carbs, cf.fats, cf.calories, cf.fiber, cf.micronutrients
+		FROM custom_foods cf
+		LEFT JOIN (
+			SELECT custom_food_id, COUNT(*) AS uses
+			FROM meals
+			WHERE user_id = $1 AND custom_food_id IS NOT NULL
+			GROUP BY custom_food_id
+		) h ON h.custom_food_id = cf.id
+		WHERE cf.user_id = $1 AND (
+			cf.name ILIKE '%' || $2 || '%' OR cf.brand ILIKE '%' || $2 || '%'
+			OR to_tsvector('french', cf.name) @@ to_tsquery('french', $3)
+			OR $2 <% cf.name
+		)
+		ORDER BY ts_rank(to_tsvector('french', cf.name), to_tsquery('french', $3))
+			+ word_similarity($2, cf.name)
+			+ $4 * LN(1 + COALESCE(h.uses, 0)) DESC,
+			cf.name
+	`, userID, query, prefixQuery(query), usageWeight)
+}
+
+func queryCustomFoods(q querier, query string, args ...interface{}) ([]CustomFood, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foods []CustomFood
+	for rows.Next() {
+		var food CustomFood
+		err := rows.Scan(
+			&food.ID, &food.UserID, &food.Name, &food.Brand,
+			&food.Proteins, &food.Carbs, &food.Fats, &food.Calories, &food.Fiber, &food.Micronutrients,
+		)
+		if err != nil {
+			return nil, err
+		}
+		foods = append(foods, food)
+	}
+	return foods, rows.Err()
+}
+
+func (db *DB) UpdateCustomFood(food *CustomFood) error {
+	query := `
+		UPDATE custom_foods
+		SET name = $1, brand = $2, proteins = $3, carbs = $4, fats = $5, calories = $6, fiber = $7, micronutrients = $8
+		WHERE id = $9 AND user_id = $10`
+
+	result, err := db.Exec(
+		query,
+		food.Name,
+		food.Brand,
+		food.Proteins,
+		food.Carbs,
+		food.Fats,
+		food.Calories,
+		food.Fiber,
+		food.Micronutrients,
+		food.ID,
+		food.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteCustomFood supprime un aliment personnalisé ; les repas déjà
+// enregistrés conservent leurs nutriments
+func (db *DB) DeleteCustomFood(userID, foodID int) error {
+	result, err := db.Exec(`DELETE FROM custom_foods WHERE id = $1 AND user_id = $2`, foodID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCustomFoodNewMeal(t *testing.T) {
+	tests := []struct {
+		name     string
+		food     CustomFood
+		amount   float64
+		calories float64
+		sodium   float64
+	}{
+		{
+			name:     "Avec micronutriments",
+			food:     CustomFood{ID: 3, Calories: 250, Micronutrients: Micronutrients{"sodium": 400}},
+			amount:   150,
+			calories: 375,
+			sodium:   600,
+		},
+		{
+			name:     "Sans micronutriments",
+			food:     CustomFood{ID: 3, Calories: 250},
+			amount:   50,
+			calories: 125,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meal := tt.food.NewMeal(1, "dejeuner", time.Now(), tt.amount)
+			if math.Abs(meal.Calories-tt.calories) > 0.001 {
+				t.Errorf("Calories = %v, attendu %v", meal.Calories, tt.calories)
+			}
+			if math.Abs(meal.Micronutrients["sodium"]-tt.sodium) > 0.001 {
+				t.Errorf("Sodium = %v, attendu %v", meal.Micronutrients["sodium"], tt.sodium)
+			}
+			if tt.food.Micronutrients == nil && meal.Micronutrients != nil {
+				t.Errorf("Micronutriments = %v, attendu aucun", meal.Micronutrients)
+			}
+			if meal.CustomFoodID == nil || *meal.CustomFoodID != tt.food.ID {
+				t.Errorf("NewMeal() = %+v", meal)
+			}
+		})
+	}
+}
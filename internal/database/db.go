@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -10,6 +11,7 @@ import (
 
 type DB struct {
 	*sql.DB
+	hits cacheHits
 }
 
 type User struct {
@@ -32,19 +34,76 @@ const (
 	Dinner    MealType = "dinner"
 )
 
+// IsValid indique si le type de repas fait partie des valeurs connues
+func (t MealType) IsValid() bool {
+	switch t {
+	case Breakfast, Snack1, Lunch, Snack2, Dinner:
+		return true
+	}
+	return false
+}
+
+// MapMealType associe un nom de repas d'une autre application, ou un ancien
+// nom du CLI (petit-dejeuner, diner...), à un type de repas
+func MapMealType(name string) (MealType, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "breakfast", "petit-déjeuner", "petit-dejeuner", "petit déjeuner":
+		return Breakfast, true
+	case "snack", "snacks", "morning snack", "collation":
+		return Snack1, true
+	case "lunch", "déjeuner", "dejeuner":
+		return Lunch, true
+	case "afternoon snack", "evening snack", "uncategorized", "goûter", "gouter":
+		return Snack2, true
+	case "dinner", "supper", "dîner", "diner":
+		return Dinner, true
+	}
+	return "", false
+}
+
 type Meal struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	MealType  string    `json:"meal_type"`
-	MealDate  time.Time `json:"meal_date"`
-	FoodID    int       `json:"food_id"`
-	FoodName  string    `json:"food_name"`
-	Amount    float64   `json:"amount"`
-	Proteins  float64   `json:"proteins"`
-	Carbs     float64   `json:"carbs"`
-	Fats      float64   `json:"fats"`
-	Calories  float64   `json:"calories"`
-	Fiber     float64   `json:"fiber"`
+	ID             int            `json:"id"`
+	UserID         int            `json:"user_id"`
+	MealType       string         `json:"meal_type"`
+	MealDate       time.Time      `json:"meal_date"`
+	FoodID         int            `json:"food_id"`
+	FoodName       string         `json:"food_name"`
+	Amount         float64        `json:"amount"`
+	Proteins       float64        `json:"proteins"`
+	Carbs          float64        `json:"carbs"`
+	Fats           float64        `json:"fats"`
+	Calories       float64        `json:"calories"`
+	Fiber          float64        `json:"fiber"`
+	RecipeID       *int           `json:"recipe_id,omitempty"`
+	CustomFoodID   *int           `json:"custom_food_id,omitempty"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
+}
+
+// DailyTotals regroupe les totaux nutritionnels d'une journée
+type DailyTotals struct {
+	Date     time.Time `json:"date"`
+	Entries  int       `json:"entries"`
+	Proteins float64   `json:"proteins"`
+	Carbs    float64   `json:"carbs"`
+	Fats     float64   `json:"fats"`
+	Calories float64   `json:"calories"`
+	Fiber    float64   `json:"fiber"`
+}
+
+// FrequentFood est un aliment souvent consommé par un utilisateur pour un
+// type de repas, avec ses valeurs moyennes pour 100g. Les micronutriments
+// pour 100g sont ceux du dernier repas qui en comporte.
+type FrequentFood struct {
+	FoodID         int            `json:"food_id"`
+	FoodName       string         `json:"food_name"`
+	MealType       string         `json:"meal_type"`
+	Uses           int            `json:"uses"`
+	Proteins       float64        `json:"proteins"`
+	Carbs          float64        `json:"carbs"`
+	Fats           float64        `json:"fats"`
+	Calories       float64        `json:"calories"`
+	Fiber          float64        `json:"fiber"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
 }
 
 type MealPlan struct {
@@ -55,17 +114,18 @@ type MealPlan struct {
 }
 
 type MealPlanItem struct {
-	ID         int      `json:"id"`
-	MealPlanID int      `json:"meal_plan_id"`
-	MealType   MealType `json:"meal_type"`
-	FoodID     int      `json:"food_id"`
-	FoodName   string   `json:"food_name"`
-	Amount     float64  `json:"amount"`
-	Proteins   float64  `json:"proteins"`
-	Carbs      float64  `json:"carbs"`
-	Fats       float64  `json:"fats"`
-	Calories   float64  `json:"calories"`
-	Fiber      float64  `json:"fiber"`
+	ID             int            `json:"id"`
+	MealPlanID     int            `json:"meal_plan_id"`
+	MealType       MealType       `json:"meal_type"`
+	FoodID         int            `json:"food_id"`
+	FoodName       string         `json:"food_name"`
+	Amount         float64        `json:"amount"`
+	Proteins       float64        `json:"proteins"`
+	Carbs          float64        `json:"carbs"`
+	Fats           float64        `json:"fats"`
+	Calories       float64        `json:"calories"`
+	Fiber          float64        `json:"fiber"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
 }
 
 func NewDB(connStr string) (*DB, error) {
@@ -76,7 +136,7 @@ func NewDB(connStr string) (*DB, error) {
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
 }
 
 func (db *DB) AddUser(user *User) error {
@@ -123,13 +183,41 @@ func (db *DB) GetUsers() ([]User, error) {
 	return users, nil
 }
 
+// querier est implémenté par *sql.DB et *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func (db *DB) AddMeal(meal *Meal) error {
+	return insertMeal(db, meal)
+}
+
+// AddMeals enregistre plusieurs repas dans une seule transaction :
+// en cas d'erreur, aucun repas n'est enregistré
+func (db *DB) AddMeals(meals []Meal) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range meals {
+		if err := insertMeal(tx, &meals[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertMeal(q querier, meal *Meal) error {
 	query := `
-		INSERT INTO meals (user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO meals (user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, recipe_id, custom_food_id, micronutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id`
 	
-	return db.QueryRow(
+	return q.QueryRow(
 		query,
 		meal.UserID,
 		meal.MealType,
@@ -142,12 +230,15 @@ func (db *DB) AddMeal(meal *Meal) error {
 		meal.Fats,
 		meal.Calories,
 		meal.Fiber,
+		meal.RecipeID,
+		meal.CustomFoodID,
+		meal.Micronutrients,
 	).Scan(&meal.ID)
 }
 
 func (db *DB) GetDailyMeals(userID int, date time.Time) ([]Meal, error) {
 	rows, err := db.DB.Query(`
-		SELECT id, user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber
+		SELECT id, user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, recipe_id, custom_food_id, micronutrients
 		FROM meals
 		WHERE user_id = $1 AND DATE(meal_date) = DATE($2)
 		ORDER BY meal_date ASC
@@ -163,7 +254,7 @@ func (db *DB) GetDailyMeals(userID int, date time.Time) ([]Meal, error) {
 		err := rows.Scan(
 			&meal.ID, &meal.UserID, &meal.MealType, &meal.MealDate,
 			&meal.FoodID, &meal.FoodName, &meal.Amount,
-			&meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Calories, &meal.Fiber,
+			&meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Calories, &meal.Fiber, &meal.RecipeID, &meal.CustomFoodID, &meal.Micronutrients,
 		)
 		if err != nil {
 			return nil, err
@@ -174,6 +265,96 @@ func (db *DB) GetDailyMeals(userID int, date time.Time) ([]Meal, error) {
 	return meals, nil
 }
 
+// ScaleTo ajuste la quantité du repas et recalcule ses nutriments à partir
+// des valeurs pour 100g déduites de la quantité enregistrée
+func (m *Meal) ScaleTo(amount float64) {
+	if m.Amount <= 0 {
+		m.Amount = amount
+		return
+	}
+	ratio := amount / m.Amount
+	m.Amount = amount
+	m.Proteins *= ratio
+	m.Carbs *= ratio
+	m.Fats *= ratio
+	m.Calories *= ratio
+	m.Fiber *= ratio
+	m.Micronutrients.Scale(ratio)
+}
+
+func (db *DB) GetMeal(userID, mealID int) (*Meal, error) {
+	meal := &Meal{}
+	err := db.QueryRow(`
+		SELECT id, user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, recipe_id, custom_food_id, micronutrients
+		FROM meals
+		WHERE id = $1 AND user_id = $2
+	`, mealID, userID).Scan(
+		&meal.ID, &meal.UserID, &meal.MealType, &meal.MealDate,
+		&meal.FoodID, &meal.FoodName, &meal.Amount,
+		&meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Calories, &meal.Fiber, &meal.RecipeID, &meal.CustomFoodID, &meal.Micronutrients,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return meal, nil
+}
+
+func (db *DB) UpdateMeal(meal *Meal) error {
+	query := `
+		UPDATE meals
+		SET meal_type = $1, meal_date = $2, amount = $3, proteins = $4, carbs = $5, fats = $6, calories = $7, fiber = $8, micronutrients = $9
+		WHERE id = $10 AND user_id = $11`
+
+	result, err := db.Exec(
+		query,
+		meal.MealType,
+		meal.MealDate,
+		meal.Amount,
+		meal.Proteins,
+		meal.Carbs,
+		meal.Fats,
+		meal.Calories,
+		meal.Fiber,
+		meal.Micronutrients,
+		meal.ID,
+		meal.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteMeal(userID, mealID int) error {
+	query := `DELETE FROM meals WHERE id = $1 AND user_id = $2`
+
+	result, err := db.Exec(query, mealID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (db *DB) GetDailyTotals(userID int, date time.Time) (proteins, carbs, fats, calories, fiber float64, err error) {
 	query := `
 		SELECT 
@@ -189,6 +370,88 @@ func (db *DB) GetDailyTotals(userID int, date time.Time) (proteins, carbs, fats,
 	return
 }
 
+// GetDailyTotalsBetweenDates renvoie les totaux de chaque journée comportant
+// au moins un repas entre startDate et endDate, en une seule requête
+func (db *DB) GetDailyTotalsBetweenDates(userID int, startDate, endDate time.Time) ([]DailyTotals, error) {
+	rows, err := db.Query(`
+		SELECT
+			DATE(meal_date) as day,
+			COUNT(*) as entries,
+			COALESCE(SUM(proteins), 0) as total_proteins,
+			COALESCE(SUM(carbs), 0) as total_carbs,
+			COALESCE(SUM(fats), 0) as total_fats,
+			COALESCE(SUM(calories), 0) as total_calories,
+			COALESCE(SUM(fiber), 0) as total_fiber
+		FROM meals
+		WHERE user_id = $1 AND DATE(meal_date) >= DATE($2) AND DATE(meal_date) <= DATE($3)
+		GROUP BY day
+		ORDER BY day ASC
+	`, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []DailyTotals
+	for rows.Next() {
+		var day DailyTotals
+		err := rows.Scan(
+			&day.Date, &day.Entries,
+			&day.Proteins, &day.Carbs, &day.Fats, &day.Calories, &day.Fiber,
+		)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// GetFrequentFoods renvoie les aliments les plus souvent consommés par
+// l'utilisateur, par type de repas
+func (db *DB) GetFrequentFoods(userID, limit int) ([]FrequentFood, error) {
+	rows, err := db.Query(`
+		SELECT
+			food_id, food_name, meal_type, COUNT(*) as uses,
+			AVG(proteins * 100 / amount),
+			AVG(carbs * 100 / amount),
+			AVG(fats * 100 / amount),
+			AVG(calories * 100 / amount),
+			AVG(fiber * 100 / amount),
+			(ARRAY_AGG(micronutrients ORDER BY meal_date DESC, id DESC) FILTER (WHERE micronutrients IS NOT NULL))[1],
+			(ARRAY_AGG(amount ORDER BY meal_date DESC, id DESC) FILTER (WHERE micronutrients IS NOT NULL))[1]
+		FROM meals
+		WHERE user_id = $1 AND amount > 0 AND food_id > 0
+		GROUP BY food_id, food_name, meal_type
+		ORDER BY uses DESC, food_name ASC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foods []FrequentFood
+	for rows.Next() {
+		var food FrequentFood
+		var amount sql.NullFloat64
+		err := rows.Scan(
+			&food.FoodID, &food.FoodName, &food.MealType, &food.Uses,
+			&food.Proteins, &food.Carbs, &food.Fats, &food.Calories, &food.Fiber,
+			&food.Micronutrients, &amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Valid {
+			food.Micronutrients.Scale(100 / amount.Float64)
+		}
+		foods = append(foods, food)
+	}
+	return foods, rows.Err()
+}
+
 func (db *DB) CreateMealPlan(plan *MealPlan) error {
 	query := `
 		INSERT INTO meal_plans (user_id, name, description)
@@ -222,9 +485,140 @@ func (db *DB) GetMealPlans(userID int) ([]MealPlan, error) {
 	return plans, rows.Err()
 }
 
+// CreateMealPlanWithItems crée une journée type et ses éléments dans une
+// seule transaction
+func (db *DB) CreateMealPlanWithItems(plan *MealPlan, items []MealPlanItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO meal_plans (user_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id`, plan.UserID, plan.Name, plan.Description).Scan(&plan.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].MealPlanID = plan.ID
+		if err := insertMealPlanItem(tx, &items[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db *DB) UpdateMealPlan(plan *MealPlan) error {
+	query := `UPDATE meal_plans SET name = $1, description = $2 WHERE id = $3`
+
+	result, err := db.Exec(query, plan.Name, plan.Description, plan.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteMealPlan supprime une journée type et tous ses éléments
+func (db *DB) DeleteMealPlan(planID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Les éléments sont supprimés explicitement pour les bases créées
+	// avant l'ajout de ON DELETE CASCADE
+	if _, err := tx.Exec(`DELETE FROM meal_plan_items WHERE meal_plan_id = $1`, planID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM meal_plans WHERE id = $1`, planID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// DuplicateMealPlan crée une copie d'une journée type et de ses éléments
+// sous un nouveau nom
+func (db *DB) DuplicateMealPlan(planID int, name string) (*MealPlan, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	plan := &MealPlan{}
+	err = tx.QueryRow(`
+		INSERT INTO meal_plans (user_id, name, description)
+		SELECT user_id, $2, description FROM meal_plans WHERE id = $1
+		RETURNING id, user_id, name, COALESCE(description, '')
+	`, planID, name).Scan(&plan.ID, &plan.UserID, &plan.Name, &plan.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO meal_plan_items (meal_plan_id, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients)
+		SELECT $2, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients
+		FROM meal_plan_items
+		WHERE meal_plan_id = $1
+		ORDER BY id
+	`, planID, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (db *DB) GetMealPlan(planID int) (*MealPlan, error) {
+	plan := &MealPlan{}
+	err := db.QueryRow(`
+		SELECT id, user_id, name, COALESCE(description, '')
+		FROM meal_plans
+		WHERE id = $1
+	`, planID).Scan(&plan.ID, &plan.UserID, &plan.Name, &plan.Description)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
 func (db *DB) GetMealPlanItems(planID int) ([]MealPlanItem, error) {
-	rows, err := db.Query(`
-		SELECT id, meal_plan_id, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber
+	return queryMealPlanItems(db, planID)
+}
+
+func queryMealPlanItems(q querier, planID int) ([]MealPlanItem, error) {
+	rows, err := q.Query(`
+		SELECT id, meal_plan_id, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients
 		FROM meal_plan_items
 		WHERE meal_plan_id = $1
 		ORDER BY id
@@ -249,6 +643,7 @@ func (db *DB) GetMealPlanItems(planID int) ([]MealPlanItem, error) {
 			&item.Fats,
 			&item.Calories,
 			&item.Fiber,
+			&item.Micronutrients,
 		)
 		if err != nil {
 			return nil, err
@@ -259,12 +654,16 @@ func (db *DB) GetMealPlanItems(planID int) ([]MealPlanItem, error) {
 }
 
 func (db *DB) AddMealPlanItem(item *MealPlanItem) error {
+	return insertMealPlanItem(db, item)
+}
+
+func insertMealPlanItem(q querier, item *MealPlanItem) error {
 	query := `
-		INSERT INTO meal_plan_items (meal_plan_id, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO meal_plan_items (meal_plan_id, meal_type, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id`
 
-	return db.QueryRow(
+	return q.QueryRow(
 		query,
 		item.MealPlanID,
 		item.MealType,
@@ -276,6 +675,7 @@ func (db *DB) AddMealPlanItem(item *MealPlanItem) error {
 		item.Fats,
 		item.Calories,
 		item.Fiber,
+		item.Micronutrients,
 	).Scan(&item.ID)
 }
 
@@ -319,9 +719,64 @@ func (db *DB) DeleteMealPlanItem(itemID int) error {
 	return nil
 }
 
+// mealTimes donne l'heure à laquelle un repas issu d'une journée type est
+// enregistré, pour que les repas de la journée restent dans l'ordre
+var mealTimes = map[MealType]time.Duration{
+	Breakfast: 8 * time.Hour,
+	Snack1:    10*time.Hour + 30*time.Minute,
+	Lunch:     12*time.Hour + 30*time.Minute,
+	Snack2:    16 * time.Hour,
+	Dinner:    19*time.Hour + 30*time.Minute,
+}
+
+// ApplyMealPlan copie tous les éléments d'une journée type dans les repas
+// de l'utilisateur pour la date donnée, en multipliant les quantités par
+// factor. Tous les repas sont enregistrés dans une seule transaction.
+func (db *DB) ApplyMealPlan(planID, userID int, date time.Time, factor float64) ([]Meal, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	items, err := queryMealPlanItems(tx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	meals := make([]Meal, 0, len(items))
+	for _, item := range items {
+		meal := Meal{
+			UserID:   userID,
+			MealType: string(item.MealType),
+			MealDate: day.Add(mealTimes[item.MealType]),
+			FoodID:   item.FoodID,
+			FoodName: item.FoodName,
+			Amount:   item.Amount * factor,
+			Proteins: item.Proteins * factor,
+			Carbs:    item.Carbs * factor,
+			Fats:     item.Fats * factor,
+			Calories: item.Calories * factor,
+			Fiber:    item.Fiber * factor,
+			// Les micronutriments sont copiés pour ne pas modifier l'élément
+			Micronutrients: Micronutrients(nil).Add(item.Micronutrients, factor),
+		}
+		if err := insertMeal(tx, &meal); err != nil {
+			return nil, err
+		}
+		meals = append(meals, meal)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return meals, nil
+}
+
 func (db *DB) GetMealsBetweenDates(userID int, startDate, endDate time.Time) ([]Meal, error) {
 	rows, err := db.Query(`
-		SELECT id, user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber
+		SELECT id, user_id, meal_type, meal_date, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, recipe_id, custom_food_id, micronutrients
 		FROM meals
 		WHERE user_id = $1 AND meal_date >= $2 AND meal_date <= $3
 		ORDER BY meal_date ASC
@@ -337,7 +792,7 @@ func (db *DB) GetMealsBetweenDates(userID int, startDate, endDate time.Time) ([]
 		err := rows.Scan(
 			&meal.ID, &meal.UserID, &meal.MealType, &meal.MealDate,
 			&meal.FoodID, &meal.FoodName, &meal.Amount,
-			&meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Calories, &meal.Fiber,
+			&meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Calories, &meal.Fiber, &meal.RecipeID, &meal.CustomFoodID, &meal.Micronutrients,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMealScaleTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		meal     Meal
+		amount   float64
+		expected Meal
+	}{
+		{
+			name:     "Doubler la quantité",
+			meal:     Meal{Amount: 100, Proteins: 20, Carbs: 10, Fats: 5, Calories: 165, Fiber: 2},
+			amount:   200,
+			expected: Meal{Amount: 200, Proteins: 40, Carbs: 20, Fats: 10, Calories: 330, Fiber: 4},
+		},
+		{
+			name:     "Réduire la quantité",
+			meal:     Meal{Amount: 150, Proteins: 30, Carbs: 0, Fats: 6, Calories: 240, Fiber: 0},
+			amount:   50,
+			expected: Meal{Amount: 50, Proteins: 10, Carbs: 0, Fats: 2, Calories: 80, Fiber: 0},
+		},
+		{
+			name:     "Micronutriments recalculés",
+			meal:     Meal{Amount: 100, Calories: 50, Micronutrients: Micronutrients{"sodium": 300}},
+			amount:   50,
+			expected: Meal{Amount: 50, Calories: 25, Micronutrients: Micronutrients{"sodium": 150}},
+		},
+		{
+			name:     "Quantité enregistrée nulle",
+			meal:     Meal{Amount: 0},
+			amount:   80,
+			expected: Meal{Amount: 80},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meal := tt.meal
+			meal.ScaleTo(tt.amount)
+
+			got := []float64{meal.Amount, meal.Proteins, meal.Carbs, meal.Fats, meal.Calories, meal.Fiber}
+			want := []float64{tt.expected.Amount, tt.expected.Proteins, tt.expected.Carbs, tt.expected.Fats, tt.expected.Calories, tt.expected.Fiber}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 0.0001 {
+					t.Errorf("ScaleTo(%v) = %+v, attendu %+v", tt.amount, meal, tt.expected)
+					break
+				}
+			}
+			for key, want := range tt.expected.Micronutrients {
+				if math.Abs(meal.Micronutrients[key]-want) > 0.0001 {
+					t.Errorf("ScaleTo(%v) micronutriment %s = %v, attendu %v", tt.amount, key, meal.Micronutrients[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMapMealType(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected MealType
+		ok       bool
+	}{
+		{name: "Breakfast", expected: Breakfast, ok: true},
+		{name: " lunch ", expected: Lunch, ok: true},
+		{name: "Dinner", expected: Dinner, ok: true},
+		{name: "Snacks", expected: Snack1, ok: true},
+		{name: "Uncategorized", expected: Snack2, ok: true},
+		{name: "Meal 6", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mealType, ok := MapMealType(tt.name)
+			if ok != tt.ok || mealType != tt.expected {
+				t.Errorf("MapMealType(%q) = %q, %v, attendu %q, %v", tt.name, mealType, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// hitFlushInterval est la durée pendant laquelle les lectures du cache sont
+// comptées en mémoire avant d'être enregistrées en une seule requête
+const hitFlushInterval = time.Minute
+
+// cacheHits compte les lectures du cache pas encore enregistrées, pour
+// éviter une écriture en base à chaque lecture
+type cacheHits struct {
+	mu        sync.Mutex
+	foods     map[int]int
+	searches  map[string]int
+	flushedAt time.Time
+}
+
+// add compte une lecture d'un aliment (foodID > 0) ou d'une recherche. Si
+// la dernière écriture date de plus de hitFlushInterval, les lectures en
+// attente sont renvoyées pour être enregistrées et due vaut true.
+func (h *cacheHits) add(foodID int, searchKey string, now time.Time) (foods map[int]int, searches map[string]int, due bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.foods == nil {
+		h.foods, h.searches = make(map[int]int), make(map[string]int)
+	}
+	if foodID > 0 {
+		h.foods[foodID]++
+	} else {
+		h.searches[searchKey]++
+	}
+	if now.Sub(h.flushedAt) < hitFlushInterval {
+		return nil, nil, false
+	}
+	foods, searches = h.take(now)
+	return foods, searches, true
+}
+
+// take renvoie et vide les lectures en attente
+func (h *cacheHits) take(now time.Time) (map[int]int, map[string]int) {
+	foods, searches := h.foods, h.searches
+	h.foods, h.searches = make(map[int]int), make(map[string]int)
+	h.flushedAt = now
+	return foods, searches
+}
+
+// FoodCacheStats décrit le contenu du cache des réponses de l'API FDC
+type FoodCacheStats struct {
+	Foods         int        `json:"foods"`
+	FoodHits      int        `json:"food_hits"`
+	Searches      int        `json:"searches"`
+	SearchHits    int        `json:"search_hits"`
+	OldestFetchAt *time.Time `json:"oldest_fetch_at,omitempty"`
+}
+
+// CachedFood renvoie le détail d'un aliment FDC enregistré dans le cache,
+// ou nil s'il n'y figure pas. La lecture est comptée en mémoire et
+// enregistrée au plus une fois par hitFlushInterval.
+func (db *DB) CachedFood(fdcID int) ([]byte, error) {
+	var data []byte
+	err := db.QueryRow(`SELECT data FROM fdc_food_cache WHERE fdc_id = $1`, fdcID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if foods, searches, due := db.hits.add(fdcID, "", time.Now()); due {
+		db.logHitsError(db.writeHits(foods, searches))
+	}
+	return data, nil
+}
+
+func (db *DB) StoreFood(fdcID int, data []byte) error {
+	_, err := db.Exec(`
+		INSERT INTO fdc_food_cache (fdc_id, data, fetched_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (fdc_id) DO UPDATE SET data = EXCLUDED.data, fetched_at = EXCLUDED.fetched_at
+	`, fdcID, data)
+	return err
+}
+
+// CachedSearch renvoie un résultat de recherche enregistré depuis moins de
+// maxAge, ou nil s'il est absent ou expiré
+func (db *DB) CachedSearch(key string, maxAge time.Duration) ([]byte, error) {
+	var data []byte
+	err := db.QueryRow(`
+		SELECT data FROM fdc_search_cache
+		WHERE search_key = $1 AND fetched_at > $2
+	`, key, time.Now().Add(-maxAge)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if foods, searches, due := db.hits.add(0, key, time.Now()); due {
+		db.logHitsError(db.writeHits(foods, searches))
+	}
+	return data, nil
+}
+
+// FlushCacheHits enregistre les lectures du cache comptées en mémoire
+func (db *DB) FlushCacheHits() error {
+	db.hits.mu.Lock()
+	foods, searches := db.hits.take(time.Now())
+	db.hits.mu.Unlock()
+	return db.writeHits(foods, searches)
+}
+
+// Close enregistre les lectures du cache en attente puis ferme la connexion
+func (db *DB) Close() error {
+	db.logHitsError(db.FlushCacheHits())
+	return db.DB.Close()
+}
+
+// writeHits ajoute les lectures comptées aux compteurs des tables du cache
+func (db *DB) writeHits(foods map[int]int, searches map[string]int) error {
+	if len(foods) > 0 {
+		ids, counts := make([]int64, 0, len(foods)), make([]int64, 0, len(foods))
+		for id, count := range foods {
+			ids, counts = append(ids, int64(id)), append(counts, int64(count))
+		}
+		_, err := db.Exec(`
+			UPDATE fdc_food_cache c SET hits = c.hits + h.count
+			FROM unnest($1::int[], $2::int[]) AS h(fdc_id, count)
+			WHERE c.fdc_id = h.fdc_id
+		`, pq.Array(ids), pq.Array(counts))
+		if err != nil {
+			return err
+		}
+	}
+	if len(searches) > 0 {
+		keys, counts := make([]string, 0, len(searches)), make([]int64, 0, len(searches))
+		for key, count := range searches {
+			keys, counts = append(keys, key), append(counts, int64(count))
+		}
+		_, err := db.Exec(`
+			UPDATE fdc_search_cache c SET hits = c.hits + h.count
+			FROM unnest($1::text[], $2::int[]) AS h(search_key, count)
+			WHERE c.search_key = h.search_key
+		`, pq.Array(keys), pq.Array(counts))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logHitsError signale l'échec de l'enregistrement des lectures, qui ne doit
+// pas faire échouer la lecture du cache
+func (db *DB) logHitsError(err error) {
+	if err != nil {
+		log.Printf("Enregistrement des lectures du cache FDC impossible: %v", err)
+	}
+}
+
+func (db *DB) StoreSearch(key string, data []byte) error {
+	_, err := db.Exec(`
+		INSERT INTO fdc_search_cache (search_key, data, fetched_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (search_key) DO UPDATE SET data = EXCLUDED.data, fetched_at = EXCLUDED.fetched_at, hits = 0
+	`, key, data)
+	return err
+}
+
+func (db *DB) GetFoodCacheStats() (*FoodCacheStats, error) {
+	if err := db.FlushCacheHits(); err != nil {
+		return nil, err
+	}
+
+	stats := &FoodCacheStats{}
+	var oldestFood, oldestSearch sql.NullTime
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM fdc_food_cache),
+			(SELECT COALESCE(SUM(hits), 0) FROM fdc_food_cache),
+			(SELECT MIN(fetched_at) FROM fdc_food_cache),
+			(SELECT COUNT(*) FROM fdc_search_cache),
+			(SELECT COALESCE(SUM(hits), 0) FROM fdc_search_cache),
+			(SELECT MIN(fetched_at) FROM fdc_search_cache)
+	`).Scan(&stats.Foods, &stats.FoodHits, &oldestFood, &stats.Searches, &stats.SearchHits, &oldestSearch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, oldest := range []sql.NullTime{oldestFood, oldestSearch} {
+		if oldest.Valid && (stats.OldestFetchAt == nil || oldest.Time.Before(*stats.OldestFetchAt)) {
+			fetchedAt := oldest.Time
+			stats.OldestFetchAt = &fetchedAt
+		}
+	}
+	return stats, nil
+}
+
+// ClearFoodCache supprime un aliment du cache, ou tout le cache (aliments
+// et recherches) si fdcID vaut 0. Renvoie le nombre d'entrées supprimées.
+func (db *DB) ClearFoodCache(fdcID int) (int64, error) {
+	if fdcID > 0 {
+		result, err := db.Exec("DELETE FROM fdc_food_cache WHERE fdc_id = $1", fdcID)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	for _, table := range []string{"fdc_food_cache", "fdc_search_cache"} {
+		result, err := tx.Exec("DELETE FROM " + table)
+		if err != nil {
+			return 0, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		removed += count
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheHitsAdd(t *testing.T) {
+	start := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	var hits cacheHits
+
+	// La première lecture est enregistrée immédiatement
+	foods, searches, due := hits.add(1, "", start)
+	if !due || !reflect.DeepEqual(foods, map[int]int{1: 1}) || len(searches) != 0 {
+		t.Fatalf("add() = %v, %v, %v, attendu la lecture de l'aliment 1", foods, searches, due)
+	}
+
+	// Les suivantes sont comptées en mémoire jusqu'à l'intervalle suivant
+	hits.add(1, "", start.Add(time.Second))
+	hits.add(2, "", start.Add(2*time.Second))
+	if _, _, due := hits.add(0, "oats", start.Add(3*time.Second)); due {
+		t.Fatal("add() avant hitFlushInterval, attendu aucune écriture")
+	}
+
+	foods, searches, due = hits.add(1, "", start.Add(hitFlushInterval))
+	if !due {
+		t.Fatal("add() après hitFlushInterval, attendu une écriture")
+	}
+	if !reflect.DeepEqual(foods, map[int]int{1: 2, 2: 1}) || !reflect.DeepEqual(searches, map[string]int{"oats": 1}) {
+		t.Errorf("lectures = %v, %v, attendu aliments 1:2 2:1 et recherche oats:1", foods, searches)
+	}
+	if len(hits.foods) != 0 || len(hits.searches) != 0 {
+		t.Errorf("lectures en attente = %v, %v, attendu aucune", hits.foods, hits.searches)
+	}
+}
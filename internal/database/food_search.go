@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/lib/pq"
+)
+
+// ErrRemoteSearch enveloppe les erreurs du fournisseur distant renvoyées par
+// SearchFoods, pour les distinguer de celles de la base de données
+var ErrRemoteSearch = errors.New("recherche distante")
+
+// usageWeight pondère, dans le score d'une recherche, le nombre de fois où
+// l'utilisateur a déjà consommé l'aliment
+const usageWeight = 0.5
+
+// SearchFoods recherche des aliments FDC d'abord dans la base locale (voir
+// SearchKnownFoods), puis auprès de l'API remote. Les résultats forment une
+// seule liste paginée : tous les aliments connus, suivis de ceux de l'API.
+// Les aliments de l'API déjà présents parmi les résultats locaux sont
+// écartés, une page peut donc en contenir moins que la taille demandée.
+// L'API n'est pas interrogée pour une page formée des seuls aliments connus.
+// Si elle échoue, les résultats locaux sont renvoyés lorsqu'il y en a.
+// Avec un LocalProvider, qui lit la même base, seuls les aliments connus
+// sont renvoyés.
+func (db *DB) SearchFoods(ctx context.Context, remote fdc.Provider, userID int, query string, opts fdc.SearchOptions) (*fdc.SearchResponse, error) {
+	known, err := db.SearchKnownFoods(ctx, userID, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, local := remote.(*LocalProvider); local {
+		return known, nil
+	}
+
+	// Positions, parmi les résultats de l'API, des aliments qui complètent
+	// la page après les aliments connus
+	start := (opts.Page()-1)*opts.Size() - known.TotalHits
+	from, to := max(start, 0), start+opts.Size()
+	if to <= 0 {
+		return withRemoteTotal(known, remote, query, opts), nil
+	}
+	foods, remoteHits, err := searchRemoteRange(ctx, remote, query, opts, from, to)
+	if err != nil {
+		if len(known.Foods) == 0 {
+			return nil, fmt.Errorf("%w: %w", ErrRemoteSearch, err)
+		}
+		log.Printf("Recherche FDC '%s' indisponible, résultats locaux uniquement: %v", query, err)
+		return known, nil
+	}
+
+	if known.TotalHits > 0 && len(foods) > 0 {
+		if foods, err = db.withoutKnownFoods(ctx, userID, query, opts, foods); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &fdc.SearchResponse{
+		Foods:       append(known.Foods, foods...),
+		TotalHits:   known.TotalHits + remoteHits,
+		CurrentPage: opts.Page(),
+	}
+	result.TotalPages = (result.TotalHits + opts.Size() - 1) / opts.Size()
+	return result, nil
+}
+
+// cachedSearcher est implémenté par les fournisseurs qui conservent les
+// recherches, comme *fdc.Client
+type cachedSearcher interface {
+	CachedSearchWithOptions(query string, opts fdc.SearchOptions) *fdc.SearchResponse
+}
+
+// withRemoteTotal ajoute à une page formée des seuls aliments connus le
+// nombre de résultats de l'API, s'il est en cache. Sinon, une page de plus
+// est annoncée pour que les résultats de l'API restent accessibles ; leur
+// nombre sera connu en l'atteignant.
+func withRemoteTotal(known *fdc.SearchResponse, remote fdc.Provider, query string, opts fdc.SearchOptions) *fdc.SearchResponse {
+	if cache, ok := remote.(cachedSearcher); ok {
+		opts.PageNumber = 1
+		if first := cache.CachedSearchWithOptions(query, opts); first != nil {
+			known.TotalHits += first.TotalHits
+			known.TotalPages = (known.TotalHits + opts.Size() - 1) / opts.Size()
+			return known
+		}
+	}
+	known.TotalPages = known.TotalHits/opts.Size() + 1
+	return known
+}
+
+// searchRemoteRange renvoie les résultats de l'API aux positions [from, to)
+// et leur nombre total. Les pages de l'API couvrant l'intervalle, qui ne
+// doit pas être vide, sont demandées avec la taille de page des options.
+func searchRemoteRange(ctx context.Context, remote fdc.Provider, query string, opts fdc.SearchOptions, from, to int) ([]fdc.Food, int, error) {
+	size := opts.Size()
+	var foods []fdc.Food
+	for page := from/size + 1; ; page++ {
+		opts.PageNumber = page
+		result, err := remote.SearchFoodsWithOptions(ctx, query, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i, food := range result.Foods {
+			if position := (page-1)*size + i; position >= from && position < to {
+				foods = append(foods, food)
+			}
+		}
+		if page*size >= to || page*size >= result.TotalHits || len(result.Foods) < size {
+			return foods, result.TotalHits, nil
+		}
+	}
+}
+
+// withoutKnownFoods retire de foods les aliments qui figurent parmi les
+// résultats locaux de la recherche
+func (db *DB) withoutKnownFoods(ctx context.Context, userID int, query string, opts fdc.SearchOptions, foods []fdc.Food) ([]fdc.Food, error) {
+	ids := make([]int64, len(foods))
+	for i, food := range foods {
+		ids[i] = int64(food.FdcID)
+	}
+	args := append(knownFoodsArgs(userID, query, opts), pq.Array(ids))
+	rows, err := db.QueryContext(ctx, knownFoodsQuery+"SELECT fdc_id FROM matches WHERE fdc_id = ANY($7)", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	remaining := foods[:0]
+	for _, food := range foods {
+		if !known[food.FdcID] {
+			remaining = append(remaining, food)
+		}
+	}
+	return remaining, nil
+}
+
+// SearchKnownFoods recherche parmi les aliments FDC enregistrés localement
+// (importés ou mis en cache) sans passer par l'API. Par défaut, les résultats
+// sont classés par pertinence : correspondance plein texte des mots ou de
+// leurs préfixes, similarité en trigrammes pour les fautes de frappe, et
+// aliments déjà consommés par l'utilisateur en tête.
+func (db *DB) SearchKnownFoods(ctx context.Context, userID int, query string, opts fdc.SearchOptions) (*fdc.SearchResponse, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return &fdc.SearchResponse{CurrentPage: opts.Page()}, nil
+	}
+
+	args := knownFoodsArgs(userID, query, opts)
+	rows, err := db.QueryContext(ctx, knownFoodsQuery+`
+		SELECT fdc_id, description, data_type, category, data, COUNT(*) OVER ()
+		FROM matches
+		ORDER BY `+knownFoodsOrder(opts)+`
+		LIMIT $7 OFFSET $8
+	`, append(args, opts.Size(), (opts.Page()-1)*opts.Size())...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &fdc.SearchResponse{CurrentPage: opts.Page()}
+	for rows.Next() {
+		var food fdc.Food
+		var data []byte
+		err := rows.Scan(&food.FdcID, &food.Description, &food.DataType, &food.FoodCategory.Description, &data, &result.TotalHits)
+		if err != nil {
+			return nil, err
+		}
+		// Les aliments du cache sont renvoyés tels que reçus de l'API
+		if data != nil {
+			if err := json.Unmarshal(data, &food); err != nil {
+				return nil, err
+			}
+		}
+		result.Foods = append(result.Foods, food)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Au-delà de la dernière page, aucune ligne ne porte le nombre total
+	if len(result.Foods) == 0 && opts.Page() > 1 {
+		err := db.QueryRowContext(ctx, knownFoodsQuery+"SELECT COUNT(*) FROM matches", args...).Scan(&result.TotalHits)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.TotalPages = (result.TotalHits + opts.Size() - 1) / opts.Size()
+
+	// Les nutriments des aliments importés sont chargés à part ; ceux du
+	// cache, absents de la table foods, n'ont aucune ligne dans food_nutrients
+	if err := NewLocalProvider(db).loadNutrients(ctx, result.Foods); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// knownFoodsQuery sélectionne, dans la table matches, les aliments importés
+// ou en cache correspondant à la requête ($2, ou $3 en plein texte), aux
+// types de données ($5) et au fabricant ($6), avec leur score de pertinence
+// pour l'utilisateur $1
+const knownFoodsQuery = `
+	WITH history AS (
+		SELECT food_id, COUNT(*) AS uses
+		FROM meals
+		WHERE user_id = $1 AND food_id > 0 AND recipe_id IS NULL AND custom_food_id IS NULL
+		GROUP BY food_id
+	), candidates AS (
+		SELECT f.fdc_id, f.description, f.data_type, COALESCE(c.description, '') AS category,
+			'' AS brand_owner, NULL::jsonb AS data
+		FROM foods f
+		LEFT JOIN food_categories c ON c.id = f.category_id
+		UNION ALL
+		SELECT fc.fdc_id, fc.data->>'description', COALESCE(fc.data->>'dataType', ''), '',
+			COALESCE(fc.data->>'brandOwner', ''), fc.data
+		FROM fdc_food_cache fc
+		WHERE NOT EXISTS (SELECT 1 FROM foods f WHERE f.fdc_id = fc.fdc_id)
+	), matches AS (
+		SELECT k.*,
+			ts_rank(to_tsvector('english', k.description), to_tsquery('english', $3))
+			+ word_similarity($2, k.description)
+			+ $4 * LN(1 + COALESCE(h.uses, 0)) AS score
+		FROM candidates k
+		LEFT JOIN history h ON h.food_id = k.fdc_id
+		WHERE (to_tsvector('english', k.description) @@ to_tsquery('english', $3) OR $2 <% k.description)
+			AND k.data_type = ANY($5)
+			AND ($6 = '' OR LOWER(k.brand_owner) = LOWER($6))
+	)
+`
+
+// knownFoodsArgs renvoie les paramètres $1 à $6 de knownFoodsQuery
+func knownFoodsArgs(userID int, query string, opts fdc.SearchOptions) []interface{} {
+	query = strings.TrimSpace(query)
+	return []interface{}{userID, query, prefixQuery(query), usageWeight, pq.Array(opts.Types()), opts.BrandOwner}
+}
+
+// Colonnes locales correspondant aux tris de l'API. La date de publication
+// n'étant pas importée, ce tri conserve l'ordre de pertinence.
+var knownFoodsSorts = map[string]string{
+	fdc.SortByDescription: "LOWER(description)",
+	fdc.SortByDataType:    "data_type",
+	fdc.SortByFdcID:       "fdc_id",
+}
+
+// knownFoodsOrder renvoie la clause ORDER BY d'une recherche locale
+func knownFoodsOrder(opts fdc.SearchOptions) string {
+	relevance := "score DESC, LENGTH(description), fdc_id"
+	column, ok := knownFoodsSorts[opts.SortBy]
+	if !ok {
+		return relevance
+	}
+	if opts.SortOrder == "desc" {
+		return column + " DESC, " + relevance
+	}
+	return column + " ASC, " + relevance
+}
+
+// prefixQuery transforme une requête libre en requête plein texte dont chaque
+// mot peut être un préfixe : « chick bre » trouve « Chicken, breast »
+func prefixQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+func TestPrefixQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "chick bre", expected: "chick:* & bre:*"},
+		{query: "Pommes", expected: "pommes:*"},
+		{query: "  yaourt  nature ", expected: "yaourt:* & nature:*"},
+		{query: "beurre d'arachide", expected: "beurre:* & d:* & arachide:*"},
+		{query: "lait 2%", expected: "lait:* & 2:*"},
+		{query: "café & (sucre | !lait)", expected: "café:* & sucre:* & lait:*"},
+		{query: "!&:*", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := prefixQuery(tt.query); got != tt.expected {
+				t.Errorf("prefixQuery(%q) = %q, attendu %q", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKnownFoodsOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     fdc.SearchOptions
+		expected string
+	}{
+		{name: "Pertinence", opts: fdc.SearchOptions{}, expected: "score DESC, LENGTH(description), fdc_id"},
+		{name: "Description", opts: fdc.SearchOptions{SortBy: fdc.SortByDescription}, expected: "LOWER(description) ASC, score DESC, LENGTH(description), fdc_id"},
+		{name: "Identifiant décroissant", opts: fdc.SearchOptions{SortBy: fdc.SortByFdcID, SortOrder: "desc"}, expected: "fdc_id DESC, score DESC, LENGTH(description), fdc_id"},
+		{name: "Date de publication", opts: fdc.SearchOptions{SortBy: fdc.SortByPublishedDate}, expected: "score DESC, LENGTH(description), fdc_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := knownFoodsOrder(tt.opts); got != tt.expected {
+				t.Errorf("knownFoodsOrder() = %q, attendu %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// pagedProvider simule une API renvoyant total aliments, d'identifiants 1 à
+// total, et note les pages demandées
+type pagedProvider struct {
+	fdc.Provider
+	total int
+	pages []int
+}
+
+func (p *pagedProvider) SearchFoodsWithOptions(ctx context.Context, query string, opts fdc.SearchOptions) (*fdc.SearchResponse, error) {
+	p.pages = append(p.pages, opts.Page())
+	result := &fdc.SearchResponse{TotalHits: p.total, CurrentPage: opts.Page()}
+	for id := (opts.Page()-1)*opts.Size() + 1; id <= min(opts.Page()*opts.Size(), p.total); id++ {
+		result.Foods = append(result.Foods, fdc.Food{FdcID: id})
+	}
+	return result, nil
+}
+
+func TestSearchRemoteRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		total    int
+		expected []int
+		pages    []int
+	}{
+		{name: "Page complétée par l'API", from: 0, to: 3, total: 20, expected: []int{1, 2, 3}, pages: []int{1}},
+		{name: "Intervalle à cheval sur deux pages", from: 3, to: 8, total: 20, expected: []int{4, 5, 6, 7, 8}, pages: []int{1, 2}},
+		{name: "Dernière page incomplète", from: 15, to: 20, total: 17, expected: []int{16, 17}, pages: []int{4}},
+		{name: "Au-delà des résultats", from: 25, to: 30, total: 17, pages: []int{6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &pagedProvider{total: tt.total}
+			foods, total, err := searchRemoteRange(context.Background(), remote, "riz", fdc.SearchOptions{PageSize: 5}, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("searchRemoteRange() erreur: %v", err)
+			}
+			var ids []int
+			for _, food := range foods {
+				ids = append(ids, food.FdcID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Aliments = %v, attendu %v", ids, tt.expected)
+			}
+			if total != tt.total {
+				t.Errorf("Total = %d, attendu %d", total, tt.total)
+			}
+			if !reflect.DeepEqual(remote.pages, tt.pages) {
+				t.Errorf("Pages demandées = %v, attendu %v", remote.pages, tt.pages)
+			}
+		})
+	}
+}
+
+// cachedProvider simule un client dont la première page de recherche est en
+// cache
+type cachedProvider struct {
+	pagedProvider
+}
+
+func (p *cachedProvider) CachedSearchWithOptions(query string, opts fdc.SearchOptions) *fdc.SearchResponse {
+	return &fdc.SearchResponse{TotalHits: p.total}
+}
+
+func TestWithRemoteTotal(t *testing.T) {
+	tests := []struct {
+		name          string
+		remote        fdc.Provider
+		known         int
+		expectedHits  int
+		expectedPages int
+	}{
+		{name: "Total de l'API en cache", remote: &cachedProvider{pagedProvider{total: 12}}, known: 10, expectedHits: 22, expectedPages: 5},
+		{name: "Aucun résultat de l'API en cache", remote: &cachedProvider{}, known: 10, expectedHits: 10, expectedPages: 2},
+		{name: "Total inconnu", remote: &pagedProvider{total: 12}, known: 10, expectedHits: 10, expectedPages: 3},
+		{name: "Total inconnu, pages locales complètes", remote: &pagedProvider{total: 12}, known: 5, expectedHits: 5, expectedPages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := &fdc.SearchResponse{TotalHits: tt.known, CurrentPage: 1}
+			result := withRemoteTotal(known, tt.remote, "riz", fdc.SearchOptions{PageSize: 5})
+			if result.TotalHits != tt.expectedHits || result.TotalPages != tt.expectedPages {
+				t.Errorf("withRemoteTotal() = %d résultats, %d pages, attendu %d, %d",
+					result.TotalHits, result.TotalPages, tt.expectedHits, tt.expectedPages)
+			}
+			if remote, ok := tt.remote.(*pagedProvider); ok && len(remote.pages) > 0 {
+				t.Errorf("Pages demandées à l'API = %v, attendu aucune", remote.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/lib/pq"
+)
+
+// ImportFoods enregistre des aliments FDC (fichiers téléchargeables de
+// Foundation Foods ou SR Legacy) dans les tables locales. Les aliments déjà
+// importés sont remplacés. Les portions sans poids en grammes sont ignorées ;
+// leur nombre est renvoyé.
+func (db *DB) ImportFoods(foods []fdc.Food) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	categories, err := importCategories(tx, foods)
+	if err != nil {
+		return 0, err
+	}
+	if err := importNutrients(tx, foods); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(foods))
+	for _, food := range foods {
+		var categoryID sql.NullInt64
+		if id, ok := categories[food.Category()]; ok {
+			categoryID = sql.NullInt64{Int64: int64(id), Valid: true}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO foods (fdc_id, data_type, description, category_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (fdc_id) DO UPDATE
+			SET data_type = EXCLUDED.data_type, description = EXCLUDED.description, category_id = EXCLUDED.category_id
+		`, food.FdcID, food.DataType, food.Description, categoryID)
+		if err != nil {
+			return 0, fmt.Errorf("aliment %d: %v", food.FdcID, err)
+		}
+		ids = append(ids, int64(food.FdcID))
+	}
+
+	// Les nutriments et portions des aliments réimportés sont remplacés
+	for _, table := range []string{"food_nutrients", "food_portions"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE fdc_id = ANY($1)", pq.Array(ids)); err != nil {
+			return 0, err
+		}
+	}
+
+	nutrientRows := make([][]interface{}, 0, len(foods)*50)
+	portionRows := make([][]interface{}, 0, len(foods)*5)
+	skipped := 0
+	for _, food := range foods {
+		seen := make(map[int]bool)
+		for _, n := range food.Nutrients {
+			amount := n.Normalize()
+			if amount.ID == 0 || seen[amount.ID] {
+				continue
+			}
+			seen[amount.ID] = true
+			nutrientRows = append(nutrientRows, []interface{}{food.FdcID, amount.ID, amount.Amount})
+		}
+		for i, p := range food.Portions {
+			if p.GramWeight <= 0 {
+				skipped++
+				continue
+			}
+			portionRows = append(portionRows, []interface{}{
+				food.FdcID, i + 1, p.Amount, p.Value, p.MeasureUnit.Name, p.MeasureUnit.Abbreviation,
+				p.Modifier, p.PortionDescription, p.GramWeight,
+			})
+		}
+	}
+
+	err = copyRows(tx, "food_nutrients", []string{"fdc_id", "nutrient_id", "amount"}, nutrientRows)
+	if err != nil {
+		return 0, err
+	}
+	err = copyRows(tx, "food_portions", []string{
+		"fdc_id", "seq_num", "amount", "value", "measure_unit", "measure_unit_abbreviation",
+		"modifier", "portion_description", "gram_weight",
+	}, portionRows)
+	if err != nil {
+		return 0, err
+	}
+
+	return skipped, tx.Commit()
+}
+
+// importCategories enregistre les catégories des aliments et renvoie leur
+// identifiant local, indexé par description
+func importCategories(tx *sql.Tx, foods []fdc.Food) (map[string]int, error) {
+	categories := make(map[string]int)
+	for _, food := range foods {
+		description := food.Category()
+		if _, ok := categories[description]; ok || description == "" {
+			continue
+		}
+
+		var id int
+		err := tx.QueryRow(`
+			INSERT INTO food_categories (description) VALUES ($1)
+			ON CONFLICT (description) DO UPDATE SET description = EXCLUDED.description
+			RETURNING id
+		`, description).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("catégorie %q: %v", description, err)
+		}
+		categories[description] = id
+	}
+	return categories, nil
+}
+
+func importNutrients(tx *sql.Tx, foods []fdc.Food) error {
+	seen := make(map[int]bool)
+	for _, food := range foods {
+		for _, n := range food.Nutrients {
+			amount := n.Normalize()
+			if amount.ID == 0 || seen[amount.ID] {
+				continue
+			}
+			seen[amount.ID] = true
+
+			name := amount.Name
+			if name == "" {
+				name = fmt.Sprintf("Nutriment %d", amount.ID)
+			}
+			_, err := tx.Exec(`
+				INSERT INTO nutrients (id, number, name, unit_name) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (id) DO UPDATE
+				SET number = EXCLUDED.number, name = EXCLUDED.name, unit_name = EXCLUDED.unit_name
+			`, amount.ID, amount.Number, name, strings.ToLower(amount.UnitName))
+			if err != nil {
+				return fmt.Errorf("nutriment %d: %v", amount.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyRows insère les lignes avec COPY, bien plus rapide que des INSERT
+// successifs pour les centaines de milliers de nutriments des fichiers FDC
+func copyRows(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("%s: %v", table, err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("%s: %v", table, err)
+	}
+	return stmt.Close()
+}
+
+// LocalProvider fournit les aliments importés dans la base locale, avec les
+// mêmes opérations que le client de l'API FDC
+type LocalProvider struct {
+	db *DB
+}
+
+var _ fdc.Provider = (*LocalProvider)(nil)
+
+func NewLocalProvider(db *DB) *LocalProvider {
+	return &LocalProvider{db: db}
+}
+
+// SearchFoodsContext recherche les aliments importés ou mis en cache,
+// classés par pertinence (voir SearchKnownFoods)
+func (p *LocalProvider) SearchFoodsContext(ctx context.Context, query string) (*fdc.SearchResponse, error) {
+	return p.SearchFoodsWithOptions(ctx, query, fdc.SearchOptions{})
+}
+
+func (p *LocalProvider) SearchFoodsWithOptions(ctx context.Context, query string, opts fdc.SearchOptions) (*fdc.SearchResponse, error) {
+	return p.db.SearchKnownFoods(ctx, 0, query, opts)
+}
+
+// GetFoodContext renvoie un aliment importé avec ses nutriments et ses
+// portions, ou fdc.ErrNotFound s'il n'a pas été importé
+func (p *LocalProvider) GetFoodContext(ctx context.Context, fdcID int) (*fdc.Food, error) {
+	foods, err := p.GetFoodsContext(ctx, []int{fdcID})
+	if err != nil {
+		return nil, err
+	}
+	if len(foods) == 0 {
+		return nil, fmt.Errorf("aliment %d absent de la base locale: %w", fdcID, fdc.ErrNotFound)
+	}
+	return &foods[0], nil
+}
+
+// GetFoodsContext renvoie les aliments importés parmi fdcIDs, dans l'ordre des
+// identifiants et sans doublon, avec leurs nutriments et leurs portions
+func (p *LocalProvider) GetFoodsContext(ctx context.Context, fdcIDs []int) ([]fdc.Food, error) {
+	ids := make([]int64, len(fdcIDs))
+	for i, id := range fdcIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT f.fdc_id, f.data_type, f.description, COALESCE(c.description, '')
+		FROM foods f
+		LEFT JOIN food_categories c ON c.id = f.category_id
+		WHERE f.fdc_id = ANY($1)
+		ORDER BY array_position($1, f.fdc_id)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	foods, err := scanLocalFoods(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.loadNutrients(ctx, foods); err != nil {
+		return nil, err
+	}
+	if err := p.loadPortions(ctx, foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+// GetFoodByBarcodeContext renvoie toujours fdc.ErrNotFound : les fichiers
+// Foundation et SR Legacy ne contiennent pas de produits de marque
+func (p *LocalProvider) GetFoodByBarcodeContext(ctx context.Context, code string) (*fdc.Food, error) {
+	return nil, fdc.ErrNotFound
+}
+
+func scanLocalFoods(rows *sql.Rows) ([]fdc.Food, error) {
+	defer rows.Close()
+
+	var foods []fdc.Food
+	for rows.Next() {
+		var food fdc.Food
+		err := rows.Scan(&food.FdcID, &food.DataType, &food.Description, &food.FoodCategory.Description)
+		if err != nil {
+			return nil, err
+		}
+		foods = append(foods, food)
+	}
+	return foods, rows.Err()
+}
+
+// loadNutrients charge en une requête les nutriments des aliments
+func (p *LocalProvider) loadNutrients(ctx context.Context, foods []fdc.Food) error {
+	if len(foods) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*fdc.Food, len(foods))
+	ids := make([]int64, 0, len(foods))
+	for i := range foods {
+		index[foods[i].FdcID] = &foods[i]
+		ids = append(ids, int64(foods[i].FdcID))
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT fn.fdc_id, n.id, COALESCE(n.number, ''), n.name, COALESCE(n.unit_name, ''), fn.amount
+		FROM food_nutrients fn
+		JOIN nutrients n ON n.id = fn.nutrient_id
+		WHERE fn.fdc_id = ANY($1)
+		ORDER BY fn.fdc_id, n.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fdcID int
+		var amount fdc.NutrientAmount
+		if err := rows.Scan(&fdcID, &amount.ID, &amount.Number, &amount.Name, &amount.UnitName, &amount.Amount); err != nil {
+			return err
+		}
+		food := index[fdcID]
+		food.Nutrients = append(food.Nutrients, amount.Nutrient())
+	}
+	return rows.Err()
+}
+
+// loadPortions charge en une requête les portions des aliments
+func (p *LocalProvider) loadPortions(ctx context.Context, foods []fdc.Food) error {
+	if len(foods) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*fdc.Food, len(foods))
+	ids := make([]int64, 0, len(foods))
+	for i := range foods {
+		index[foods[i].FdcID] = &foods[i]
+		ids = append(ids, int64(foods[i].FdcID))
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT fdc_id, COALESCE(amount, 0), COALESCE(value, 0), COALESCE(measure_unit, ''),
+			COALESCE(measure_unit_abbreviation, ''), COALESCE(modifier, ''),
+			COALESCE(portion_description, ''), gram_weight
+		FROM food_portions
+		WHERE fdc_id = ANY($1)
+		ORDER BY fdc_id, seq_num
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fdcID int
+		var portion fdc.FoodPortion
+		err := rows.Scan(
+			&fdcID, &portion.Amount, &portion.Value, &portion.MeasureUnit.Name, &portion.MeasureUnit.Abbreviation,
+			&portion.Modifier, &portion.PortionDescription, &portion.GramWeight,
+		)
+		if err != nil {
+			return err
+		}
+		food := index[fdcID]
+		food.Portions = append(food.Portions, portion)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Micronutrients associe à chaque micronutriment suivi (sodium, calcium,
+// vitamin_c...) sa quantité, stockée en JSONB
+type Micronutrients map[string]float64
+
+func (m Micronutrients) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+func (m *Micronutrients) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("micronutriments: type %T non pris en charge", src)
+	}
+}
+
+// Add ajoute les quantités de other, multipliées par ratio, et renvoie le
+// résultat. m peut être nil.
+func (m Micronutrients) Add(other Micronutrients, ratio float64) Micronutrients {
+	if len(other) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(Micronutrients, len(other))
+	}
+	for key, value := range other {
+		m[key] += value * ratio
+	}
+	return m
+}
+
+// Scale multiplie toutes les quantités par ratio
+func (m Micronutrients) Scale(ratio float64) {
+	for key := range m {
+		m[key] *= ratio
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMicronutrientsAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     Micronutrients
+		other    Micronutrients
+		ratio    float64
+		expected Micronutrients
+	}{
+		{
+			name:     "Ajout à un total vide",
+			other:    Micronutrients{"sodium": 400, "iron": 2},
+			ratio:    0.5,
+			expected: Micronutrients{"sodium": 200, "iron": 1},
+		},
+		{
+			name:     "Cumul de micronutriments différents",
+			base:     Micronutrients{"sodium": 100},
+			other:    Micronutrients{"sodium": 50, "calcium": 120},
+			ratio:    1,
+			expected: Micronutrients{"sodium": 150, "calcium": 120},
+		},
+		{
+			name:     "Rien à ajouter",
+			base:     Micronutrients{"vitamin_c": 30},
+			ratio:    2,
+			expected: Micronutrients{"vitamin_c": 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.base.Add(tt.other, tt.ratio)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Add() = %v, attendu %v", got, tt.expected)
+			}
+			for key, want := range tt.expected {
+				if math.Abs(got[key]-want) > 0.0001 {
+					t.Errorf("Add()[%s] = %v, attendu %v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMicronutrientsScan(t *testing.T) {
+	var m Micronutrients
+	if err := m.Scan([]byte(`{"sodium": 120.5, "vitamin_d": 2}`)); err != nil {
+		t.Fatalf("Scan() erreur = %v", err)
+	}
+	if m["sodium"] != 120.5 || m["vitamin_d"] != 2 {
+		t.Errorf("Scan() = %v", m)
+	}
+
+	value, err := m.Value()
+	if err != nil {
+		t.Fatalf("Value() erreur = %v", err)
+	}
+	var again Micronutrients
+	if err := again.Scan(value); err != nil || again["sodium"] != 120.5 {
+		t.Errorf("Scan(Value()) = %v, %v", again, err)
+	}
+
+	if err := m.Scan(nil); err != nil || m != nil {
+		t.Errorf("Scan(nil) = %v, %v, attendu nil", m, err)
+	}
+	if value, _ := m.Value(); value != nil {
+		t.Errorf("Value() d'un nil = %v, attendu nil", value)
+	}
+}
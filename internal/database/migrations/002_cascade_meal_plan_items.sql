@@ -0,0 +1,4 @@
+ALTER TABLE meal_plan_items DROP CONSTRAINT IF EXISTS meal_plan_items_meal_plan_id_fkey;
+ALTER TABLE meal_plan_items
+    ADD CONSTRAINT meal_plan_items_meal_plan_id_fkey
+    FOREIGN KEY (meal_plan_id) REFERENCES meal_plans(id) ON DELETE CASCADE;
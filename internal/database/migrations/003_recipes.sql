@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS recipes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    name VARCHAR(255) NOT NULL,
+    cooked_weight FLOAT NOT NULL DEFAULT 0,
+    servings FLOAT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id SERIAL PRIMARY KEY,
+    recipe_id INTEGER REFERENCES recipes(id) ON DELETE CASCADE,
+    food_id INTEGER NOT NULL,
+    food_name VARCHAR(255) NOT NULL,
+    amount FLOAT NOT NULL,
+    proteins FLOAT NOT NULL,
+    carbs FLOAT NOT NULL,
+    fats FLOAT NOT NULL,
+    calories FLOAT NOT NULL,
+    fiber FLOAT NOT NULL
+);
+
+ALTER TABLE meals ADD COLUMN IF NOT EXISTS recipe_id INTEGER REFERENCES recipes(id) ON DELETE SET NULL;
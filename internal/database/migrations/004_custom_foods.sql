@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS custom_foods (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    name VARCHAR(255) NOT NULL,
+    brand VARCHAR(255) NOT NULL DEFAULT '',
+    proteins FLOAT NOT NULL,
+    carbs FLOAT NOT NULL,
+    fats FLOAT NOT NULL,
+    calories FLOAT NOT NULL,
+    fiber FLOAT NOT NULL
+);
+
+ALTER TABLE meals ADD COLUMN IF NOT EXISTS custom_food_id INTEGER REFERENCES custom_foods(id) ON DELETE SET NULL;
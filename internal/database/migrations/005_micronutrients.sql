@@ -0,0 +1,2 @@
+ALTER TABLE meals ADD COLUMN IF NOT EXISTS micronutrients JSONB;
+ALTER TABLE recipe_ingredients ADD COLUMN IF NOT EXISTS micronutrients JSONB;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS fdc_food_cache (
+    fdc_id INTEGER PRIMARY KEY,
+    data JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hits INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS fdc_search_cache (
+    search_key TEXT PRIMARY KEY,
+    data JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hits INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS food_categories (
+    id SERIAL PRIMARY KEY,
+    description VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS nutrients (
+    id INTEGER PRIMARY KEY,
+    number VARCHAR(20),
+    name VARCHAR(255) NOT NULL,
+    unit_name VARCHAR(20)
+);
+
+CREATE TABLE IF NOT EXISTS foods (
+    fdc_id INTEGER PRIMARY KEY,
+    data_type VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL,
+    category_id INTEGER REFERENCES food_categories(id)
+);
+
+CREATE TABLE IF NOT EXISTS food_nutrients (
+    fdc_id INTEGER REFERENCES foods(fdc_id) ON DELETE CASCADE,
+    nutrient_id INTEGER REFERENCES nutrients(id),
+    amount FLOAT NOT NULL,
+    PRIMARY KEY (fdc_id, nutrient_id)
+);
+
+CREATE TABLE IF NOT EXISTS food_portions (
+    id SERIAL PRIMARY KEY,
+    fdc_id INTEGER REFERENCES foods(fdc_id) ON DELETE CASCADE,
+    seq_num INTEGER NOT NULL,
+    amount FLOAT,
+    value FLOAT,
+    measure_unit VARCHAR(100),
+    measure_unit_abbreviation VARCHAR(100),
+    modifier TEXT,
+    portion_description TEXT,
+    gram_weight FLOAT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_food_portions_fdc_id ON food_portions(fdc_id);
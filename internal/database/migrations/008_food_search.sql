@@ -0,0 +1,15 @@
+-- Recherche plein texte et approximative (fautes de frappe, pluriels,
+-- préfixes) sur les aliments enregistrés localement
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_foods_description_fts ON foods USING GIN (to_tsvector('english', description));
+CREATE INDEX IF NOT EXISTS idx_foods_description_trgm ON foods USING GIN (description gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_fdc_food_cache_description_fts ON fdc_food_cache USING GIN (to_tsvector('english', data->>'description'));
+CREATE INDEX IF NOT EXISTS idx_fdc_food_cache_description_trgm ON fdc_food_cache USING GIN ((data->>'description') gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_custom_foods_name_fts ON custom_foods USING GIN (to_tsvector('french', name));
+CREATE INDEX IF NOT EXISTS idx_custom_foods_name_trgm ON custom_foods USING GIN (name gin_trgm_ops);
+
+-- Historique des aliments consommés, pour favoriser les aliments habituels
+CREATE INDEX IF NOT EXISTS idx_meals_user_food ON meals(user_id, food_id);
@@ -0,0 +1 @@
+ALTER TABLE meal_plan_items ADD COLUMN IF NOT EXISTS micronutrients JSONB;
@@ -0,0 +1 @@
+ALTER TABLE custom_foods ADD COLUMN IF NOT EXISTS micronutrients JSONB;
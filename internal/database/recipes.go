@@ -0,0 +1,318 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Recipe est un plat composé de plusieurs aliments FDC, dont les nutriments
+// pour 100g sont déduits des ingrédients et du poids après cuisson
+type Recipe struct {
+	ID           int                `json:"id"`
+	UserID       int                `json:"user_id"`
+	Name         string             `json:"name"`
+	CookedWeight float64            `json:"cooked_weight"`
+	Servings     float64            `json:"servings"`
+	Ingredients  []RecipeIngredient `json:"ingredients"`
+}
+
+// RecipeIngredient est un aliment FDC utilisé dans une recette, avec les
+// nutriments correspondant à la quantité utilisée
+type RecipeIngredient struct {
+	ID             int            `json:"id"`
+	RecipeID       int            `json:"recipe_id"`
+	FoodID         int            `json:"food_id"`
+	FoodName       string         `json:"food_name"`
+	Amount         float64        `json:"amount"`
+	Proteins       float64        `json:"proteins"`
+	Carbs          float64        `json:"carbs"`
+	Fats           float64        `json:"fats"`
+	Calories       float64        `json:"calories"`
+	Fiber          float64        `json:"fiber"`
+	Micronutrients Micronutrients `json:"micronutrients,omitempty"`
+}
+
+// IsServingUnit indique si unit désigne une portion de recette
+func IsServingUnit(unit string) bool {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "portion", "portions", "serving", "servings":
+		return true
+	}
+	return false
+}
+
+// Weight renvoie le poids total de la recette : le poids après cuisson s'il
+// est renseigné, sinon la somme des quantités des ingrédients
+func (r *Recipe) Weight() float64 {
+	if r.CookedWeight > 0 {
+		return r.CookedWeight
+	}
+	var weight float64
+	for _, ingredient := range r.Ingredients {
+		weight += ingredient.Amount
+	}
+	return weight
+}
+
+// ServingWeight renvoie le poids d'une portion
+func (r *Recipe) ServingWeight() float64 {
+	if r.Servings <= 0 {
+		return r.Weight()
+	}
+	return r.Weight() / r.Servings
+}
+
+// Totals renvoie les nutriments de la recette entière
+func (r *Recipe) Totals() (proteins, carbs, fats, calories, fiber float64) {
+	for _, ingredient := range r.Ingredients {
+		proteins += ingredient.Proteins
+		carbs += ingredient.Carbs
+		fats += ingredient.Fats
+		calories += ingredient.Calories
+		fiber += ingredient.Fiber
+	}
+	return
+}
+
+// MacrosFor renvoie les nutriments d'une quantité (en grammes) de la
+// recette cuisinée
+func (r *Recipe) MacrosFor(amount float64) (proteins, carbs, fats, calories, fiber float64) {
+	weight := r.Weight()
+	if weight <= 0 {
+		return 0, 0, 0, 0, 0
+	}
+	ratio := amount / weight
+	proteins, carbs, fats, calories, fiber = r.Totals()
+	return proteins * ratio, carbs * ratio, fats * ratio, calories * ratio, fiber * ratio
+}
+
+// NewMeal crée le repas correspondant à une quantité (en grammes) de la
+// recette cuisinée
+func (r *Recipe) NewMeal(userID int, mealType string, date time.Time, amount float64) Meal {
+	proteins, carbs, fats, calories, fiber := r.MacrosFor(amount)
+	recipeID := r.ID
+
+	var micronutrients Micronutrients
+	if weight := r.Weight(); weight > 0 {
+		for _, ingredient := range r.Ingredients {
+			micronutrients = micronutrients.Add(ingredient.Micronutrients, amount/weight)
+		}
+	}
+
+	return Meal{
+		UserID:         userID,
+		MealType:       mealType,
+		MealDate:       date,
+		FoodName:       r.Name,
+		Amount:         amount,
+		Proteins:       proteins,
+		Carbs:          carbs,
+		Fats:           fats,
+		Calories:       calories,
+		Fiber:          fiber,
+		RecipeID:       &recipeID,
+		Micronutrients: micronutrients,
+	}
+}
+
+// CreateRecipe enregistre une recette et ses ingrédients dans une seule
+// transaction
+func (db *DB) CreateRecipe(recipe *Recipe) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO recipes (user_id, name, cooked_weight, servings)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		recipe.UserID, recipe.Name, recipe.CookedWeight, recipe.Servings,
+	).Scan(&recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRecipeIngredients(tx, recipe); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRecipeIngredients(q querier, recipe *Recipe) error {
+	query := `
+		INSERT INTO recipe_ingredients (recipe_id, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	for i := range recipe.Ingredients {
+		ingredient := &recipe.Ingredients[i]
+		ingredient.RecipeID = recipe.ID
+		err := q.QueryRow(
+			query,
+			ingredient.RecipeID,
+			ingredient.FoodID,
+			ingredient.FoodName,
+			ingredient.Amount,
+			ingredient.Proteins,
+			ingredient.Carbs,
+			ingredient.Fats,
+			ingredient.Calories,
+			ingredient.Fiber,
+			ingredient.Micronutrients,
+		).Scan(&ingredient.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRecipe renvoie une recette de l'utilisateur et ses ingrédients, ou
+// sql.ErrNoRows si elle appartient à un autre utilisateur
+func (db *DB) GetRecipe(userID, recipeID int) (*Recipe, error) {
+	recipe := &Recipe{}
+	err := db.QueryRow(`
+		SELECT id, user_id, name, cooked_weight, servings
+		FROM recipes
+		WHERE id = $1 AND user_id = $2
+	`, recipeID, userID).Scan(&recipe.ID, &recipe.UserID, &recipe.Name, &recipe.CookedWeight, &recipe.Servings)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe.Ingredients, err = queryRecipeIngredients(db, recipe.ID)
+	if err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+func (db *DB) GetRecipes(userID int) ([]Recipe, error) {
+	rows, err := db.Query(`
+		SELECT id, user_id, name, cooked_weight, servings
+		FROM recipes
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipes []Recipe
+	for rows.Next() {
+		var recipe Recipe
+		err := rows.Scan(&recipe.ID, &recipe.UserID, &recipe.Name, &recipe.CookedWeight, &recipe.Servings)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range recipes {
+		recipes[i].Ingredients, err = queryRecipeIngredients(db, recipes[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return recipes, nil
+}
+
+func queryRecipeIngredients(q querier, recipeID int) ([]RecipeIngredient, error) {
+	rows, err := q.Query(`
+		SELECT id, recipe_id, food_id, food_name, amount, proteins, carbs, fats, calories, fiber, micronutrients
+		FROM recipe_ingredients
+		WHERE recipe_id = $1
+		ORDER BY id
+	`, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingredients []RecipeIngredient
+	for rows.Next() {
+		var ingredient RecipeIngredient
+		err := rows.Scan(
+			&ingredient.ID,
+			&ingredient.RecipeID,
+			&ingredient.FoodID,
+			&ingredient.FoodName,
+			&ingredient.Amount,
+			&ingredient.Proteins,
+			&ingredient.Carbs,
+			&ingredient.Fats,
+			&ingredient.Calories,
+			&ingredient.Fiber,
+			&ingredient.Micronutrients,
+		)
+		if err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, rows.Err()
+}
+
+// UpdateRecipe met à jour une recette et remplace tous ses ingrédients
+func (db *DB) UpdateRecipe(recipe *Recipe) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE recipes SET name = $1, cooked_weight = $2, servings = $3
+		WHERE id = $4 AND user_id = $5`,
+		recipe.Name, recipe.CookedWeight, recipe.Servings, recipe.ID, recipe.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recipe_ingredients WHERE recipe_id = $1`, recipe.ID); err != nil {
+		return err
+	}
+	if err := insertRecipeIngredients(tx, recipe); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRecipe supprime une recette et ses ingrédients ; les repas déjà
+// enregistrés conservent leurs nutriments
+func (db *DB) DeleteRecipe(userID, recipeID int) error {
+	result, err := db.Exec(`DELETE FROM recipes WHERE id = $1 AND user_id = $2`, recipeID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRecipeMacros(t *testing.T) {
+	ingredients := []RecipeIngredient{
+		{FoodName: "Riz", Amount: 200, Proteins: 14, Carbs: 156, Fats: 1, Calories: 720, Fiber: 2},
+		{FoodName: "Poulet", Amount: 300, Proteins: 93, Carbs: 0, Fats: 11, Calories: 495, Fiber: 0},
+	}
+
+	tests := []struct {
+		name          string
+		recipe        Recipe
+		amount        float64
+		weight        float64
+		servingWeight float64
+		calories      float64
+		proteins      float64
+	}{
+		{
+			name:          "Poids après cuisson renseigné",
+			recipe:        Recipe{CookedWeight: 900, Servings: 3, Ingredients: ingredients},
+			amount:        300,
+			weight:        900,
+			servingWeight: 300,
+			calories:      405,
+			proteins:      35.667,
+		},
+		{
+			name:          "Poids déduit des ingrédients",
+			recipe:        Recipe{Servings: 2, Ingredients: ingredients},
+			amount:        100,
+			weight:        500,
+			servingWeight: 250,
+			calories:      243,
+			proteins:      21.4,
+		},
+		{
+			name:          "Sans nombre de portions",
+			recipe:        Recipe{CookedWeight: 1215, Ingredients: ingredients},
+			amount:        1215,
+			weight:        1215,
+			servingWeight: 1215,
+			calories:      1215,
+			proteins:      107,
+		},
+		{
+			name:   "Recette vide",
+			recipe: Recipe{Servings: 4},
+			amount: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.recipe.Weight(); math.Abs(got-tt.weight) > 0.001 {
+				t.Errorf("Weight() = %v, attendu %v", got, tt.weight)
+			}
+			if got := tt.recipe.ServingWeight(); math.Abs(got-tt.servingWeight) > 0.001 {
+				t.Errorf("ServingWeight() = %v, attendu %v", got, tt.servingWeight)
+			}
+
+			meal := tt.recipe.NewMeal(1, "dejeuner", time.Now(), tt.amount)
+			if math.Abs(meal.Calories-tt.calories) > 0.001 {
+				t.Errorf("Calories = %v, attendu %v", meal.Calories, tt.calories)
+			}
+			if math.Abs(meal.Proteins-tt.proteins) > 0.001 {
+				t.Errorf("Protéines = %v, attendu %v", meal.Proteins, tt.proteins)
+			}
+			if meal.Amount != tt.amount || meal.RecipeID == nil {
+				t.Errorf("NewMeal() = %+v", meal)
+			}
+		})
+	}
+}
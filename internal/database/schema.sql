@@ -10,6 +10,41 @@ CREATE TABLE IF NOT EXISTS users (
 
 CREATE TYPE meal_type AS ENUM ('breakfast', 'snack1', 'lunch', 'snack2', 'dinner');
 
+CREATE TABLE IF NOT EXISTS recipes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    name VARCHAR(255) NOT NULL,
+    cooked_weight FLOAT NOT NULL DEFAULT 0,
+    servings FLOAT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id SERIAL PRIMARY KEY,
+    recipe_id INTEGER REFERENCES recipes(id) ON DELETE CASCADE,
+    food_id INTEGER NOT NULL,
+    food_name VARCHAR(255) NOT NULL,
+    amount FLOAT NOT NULL,
+    proteins FLOAT NOT NULL,
+    carbs FLOAT NOT NULL,
+    fats FLOAT NOT NULL,
+    calories FLOAT NOT NULL,
+    fiber FLOAT NOT NULL,
+    micronutrients JSONB
+);
+
+CREATE TABLE IF NOT EXISTS custom_foods (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    name VARCHAR(255) NOT NULL,
+    brand VARCHAR(255) NOT NULL DEFAULT '',
+    proteins FLOAT NOT NULL,
+    carbs FLOAT NOT NULL,
+    fats FLOAT NOT NULL,
+    calories FLOAT NOT NULL,
+    fiber FLOAT NOT NULL,
+    micronutrients JSONB
+);
+
 CREATE TABLE IF NOT EXISTS meals (
     id SERIAL PRIMARY KEY,
     user_id INTEGER REFERENCES users(id),
@@ -22,7 +57,10 @@ CREATE TABLE IF NOT EXISTS meals (
     carbs FLOAT NOT NULL,
     fats FLOAT NOT NULL,
     calories FLOAT NOT NULL,
-    fiber FLOAT NOT NULL
+    fiber FLOAT NOT NULL,
+    recipe_id INTEGER REFERENCES recipes(id) ON DELETE SET NULL,
+    custom_food_id INTEGER REFERENCES custom_foods(id) ON DELETE SET NULL,
+    micronutrients JSONB
 );
 
 CREATE TABLE IF NOT EXISTS meal_plans (
@@ -34,7 +72,7 @@ CREATE TABLE IF NOT EXISTS meal_plans (
 
 CREATE TABLE IF NOT EXISTS meal_plan_items (
     id SERIAL PRIMARY KEY,
-    meal_plan_id INTEGER REFERENCES meal_plans(id),
+    meal_plan_id INTEGER REFERENCES meal_plans(id) ON DELETE CASCADE,
     meal_type VARCHAR(50) NOT NULL,
     food_id INTEGER NOT NULL,
     food_name VARCHAR(255) NOT NULL,
@@ -43,5 +81,75 @@ CREATE TABLE IF NOT EXISTS meal_plan_items (
     carbs FLOAT NOT NULL,
     fats FLOAT NOT NULL,
     calories FLOAT NOT NULL,
-    fiber FLOAT NOT NULL
+    fiber FLOAT NOT NULL,
+    micronutrients JSONB
 );
+
+CREATE TABLE IF NOT EXISTS fdc_food_cache (
+    fdc_id INTEGER PRIMARY KEY,
+    data JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hits INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS fdc_search_cache (
+    search_key TEXT PRIMARY KEY,
+    data JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hits INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS food_categories (
+    id SERIAL PRIMARY KEY,
+    description VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS nutrients (
+    id INTEGER PRIMARY KEY,
+    number VARCHAR(20),
+    name VARCHAR(255) NOT NULL,
+    unit_name VARCHAR(20)
+);
+
+CREATE TABLE IF NOT EXISTS foods (
+    fdc_id INTEGER PRIMARY KEY,
+    data_type VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL,
+    category_id INTEGER REFERENCES food_categories(id)
+);
+
+CREATE TABLE IF NOT EXISTS food_nutrients (
+    fdc_id INTEGER REFERENCES foods(fdc_id) ON DELETE CASCADE,
+    nutrient_id INTEGER REFERENCES nutrients(id),
+    amount FLOAT NOT NULL,
+    PRIMARY KEY (fdc_id, nutrient_id)
+);
+
+CREATE TABLE IF NOT EXISTS food_portions (
+    id SERIAL PRIMARY KEY,
+    fdc_id INTEGER REFERENCES foods(fdc_id) ON DELETE CASCADE,
+    seq_num INTEGER NOT NULL,
+    amount FLOAT,
+    value FLOAT,
+    measure_unit VARCHAR(100),
+    measure_unit_abbreviation VARCHAR(100),
+    modifier TEXT,
+    portion_description TEXT,
+    gram_weight FLOAT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_food_portions_fdc_id ON food_portions(fdc_id);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_foods_description_fts ON foods USING GIN (to_tsvector('english', description));
+CREATE INDEX IF NOT EXISTS idx_foods_description_trgm ON foods USING GIN (description gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_fdc_food_cache_description_fts ON fdc_food_cache USING GIN (to_tsvector('english', data->>'description'));
+CREATE INDEX IF NOT EXISTS idx_fdc_food_cache_description_trgm ON fdc_food_cache USING GIN ((data->>'description') gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_custom_foods_name_fts ON custom_foods USING GIN (to_tsvector('french', name));
+CREATE INDEX IF NOT EXISTS idx_custom_foods_name_trgm ON custom_foods USING GIN (name gin_trgm_ops);
+
+-- Historique des aliments consommés, pour favoriser les aliments habituels
+CREATE INDEX IF NOT EXISTS idx_meals_user_food ON meals(user_id, food_id);
@@ -0,0 +1,183 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// Format désigne un format d'export des repas
+type Format string
+
+const (
+	CSV      Format = "csv"
+	JSON     Format = "json"
+	Markdown Format = "md"
+)
+
+// CSVHeader est l'en-tête des fichiers CSV exportés : les valeurs
+// nutritionnelles principales, suivies d'une colonne par micronutriment
+var CSVHeader = append([]string{"Date", "Type de repas", "Aliment", "Quantité (g)", "Calories", "Protéines", "Glucides", "Lipides", "Fibres"},
+	micronutrientColumns()...)
+
+// CSVMacroColumns est le nombre de colonnes de CSVHeader qui précèdent les
+// micronutriments. Les exports antérieurs ne contiennent que celles-ci.
+const CSVMacroColumns = 9
+
+// micronutrientColumns renvoie les noms de colonnes des micronutriments,
+// dans l'ordre de fdc.Micronutrients
+func micronutrientColumns() []string {
+	columns := make([]string, len(fdc.Micronutrients))
+	for i, m := range fdc.Micronutrients {
+		columns[i] = fmt.Sprintf("%s (%s)", m.Label, m.Unit)
+	}
+	return columns
+}
+
+// ParseFormat convertit un nom de format, insensible à la casse
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return CSV, nil
+	case "json":
+		return JSON, nil
+	case "md", "markdown":
+		return Markdown, nil
+	}
+	return "", fmt.Errorf("format d'export inconnu: %s (attendu: csv, json ou md)", name)
+}
+
+// Extension renvoie l'extension de fichier associée au format
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentType renvoie le type MIME associé au format
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Write écrit les repas dans le format demandé
+func Write(w io.Writer, format Format, meals []database.Meal) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, meals)
+	case JSON:
+		return writeJSON(w, meals)
+	case Markdown:
+		return writeMarkdown(w, meals)
+	}
+	return fmt.Errorf("format d'export inconnu: %s", format)
+}
+
+func writeCSV(w io.Writer, meals []database.Meal) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	for _, meal := range meals {
+		row := []string{
+			meal.MealDate.Format("2006-01-02"),
+			meal.MealType,
+			meal.FoodName,
+			fmt.Sprintf("%.1f", meal.Amount),
+			fmt.Sprintf("%.1f", meal.Calories),
+			fmt.Sprintf("%.1f", meal.Proteins),
+			fmt.Sprintf("%.1f", meal.Carbs),
+			fmt.Sprintf("%.1f", meal.Fats),
+			fmt.Sprintf("%.1f", meal.Fiber),
+		}
+		// Une cellule vide indique un micronutriment inconnu, pas une quantité nulle
+		for _, m := range fdc.Micronutrients {
+			value, ok := meal.Micronutrients[string(m.Key)]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.2f", value))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, meals []database.Meal) error {
+	if meals == nil {
+		meals = []database.Meal{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(meals)
+}
+
+// writeMarkdown écrit un tableau par jour, suivi d'une ligne de total. Les
+// lignes sont écrites au fur et à mesure dans un tampon de taille fixe ; la
+// première erreur d'écriture est renvoyée à la fin.
+func writeMarkdown(w io.Writer, meals []database.Meal) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("# Export Macro-Tracker\n")
+
+	if len(meals) == 0 {
+		b.WriteString("\nAucun repas sur cette période.\n")
+	}
+
+	var day string
+	var calories, proteins, carbs, fats, fiber float64
+	writeTotal := func() {
+		fmt.Fprintf(b, "| **Total** | | | **%.1f** | **%.1f** | **%.1f** | **%.1f** | **%.1f** |\n",
+			calories, proteins, carbs, fats, fiber)
+	}
+
+	for _, meal := range meals {
+		date := meal.MealDate.Format("2006-01-02")
+		if date != day {
+			if day != "" {
+				writeTotal()
+			}
+			day = date
+			calories, proteins, carbs, fats, fiber = 0, 0, 0, 0, 0
+
+			fmt.Fprintf(b, "\n## %s\n\n", date)
+			b.WriteString("| " + strings.Join(CSVHeader[1:CSVMacroColumns], " | ") + " |\n")
+			b.WriteString("|" + strings.Repeat(" --- |", CSVMacroColumns-1) + "\n")
+		}
+
+		fmt.Fprintf(b, "| %s | %s | %.1f | %.1f | %.1f | %.1f | %.1f | %.1f |\n",
+			meal.MealType, escapeMarkdown(meal.FoodName), meal.Amount,
+			meal.Calories, meal.Proteins, meal.Carbs, meal.Fats, meal.Fiber)
+
+		calories += meal.Calories
+		proteins += meal.Proteins
+		carbs += meal.Carbs
+		fats += meal.Fats
+		fiber += meal.Fiber
+	}
+	if day != "" {
+		writeTotal()
+	}
+
+	return b.Flush()
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
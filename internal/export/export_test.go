@@ -0,0 +1,119 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+var testMeals = []database.Meal{
+	{
+		MealType: "dejeuner", MealDate: time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC),
+		FoodName: "Chicken, breast", Amount: 150, Calories: 247.5, Proteins: 46.5, Carbs: 0, Fats: 5.4, Fiber: 0,
+		Micronutrients: database.Micronutrients{"sodium": 111, "iron": 1.2},
+	},
+	{
+		MealType: "diner", MealDate: time.Date(2024, 3, 16, 19, 0, 0, 0, time.UTC),
+		FoodName: "Rice | white", Amount: 200, Calories: 260, Proteins: 5.4, Carbs: 56, Fats: 0.6, Fiber: 0.8,
+	},
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{input: "csv", expected: CSV},
+		{input: "JSON", expected: JSON},
+		{input: "markdown", expected: Markdown},
+		{input: "md", expected: Markdown},
+		{input: "xlsx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			format, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) erreur = %v, attendu erreur: %v", tt.input, err, tt.wantErr)
+			}
+			if format != tt.expected {
+				t.Errorf("ParseFormat(%q) = %q, attendu %q", tt.input, format, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, testMeals); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+
+	expected := "Date,Type de repas,Aliment,Quantité (g),Calories,Protéines,Glucides,Lipides,Fibres," +
+		"Sodium (mg),Sucres (g),Acides gras saturés (g),Cholestérol (mg),Potassium (mg),Calcium (mg),Fer (mg)," +
+		"Vitamine A (µg),Vitamine C (mg),Vitamine D (µg),Vitamine B12 (µg)\n" +
+		"2024-03-15,dejeuner,\"Chicken, breast\",150.0,247.5,46.5,0.0,5.4,0.0,111.00,,,,,,1.20,,,,\n" +
+		"2024-03-16,diner,Rice | white,200.0,260.0,5.4,56.0,0.6,0.8,,,,,,,,,,,\n"
+	if buf.String() != expected {
+		t.Errorf("CSV obtenu:\n%s\nattendu:\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, nil); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("JSON sans repas = %s, attendu []", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, JSON, testMeals); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+	var meals []database.Meal
+	if err := json.Unmarshal(buf.Bytes(), &meals); err != nil {
+		t.Fatalf("JSON invalide: %v", err)
+	}
+	if len(meals) != 2 || meals[1].FoodName != "Rice | white" || meals[0].Micronutrients["iron"] != 1.2 {
+		t.Errorf("Repas décodés = %+v", meals)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Markdown, testMeals); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		"## 2024-03-15",
+		"## 2024-03-16",
+		"| dejeuner | Chicken, breast | 150.0 | 247.5 | 46.5 | 0.0 | 5.4 | 0.0 |",
+		"| diner | Rice \\| white | 200.0 |",
+		"| **Total** | | | **260.0** |",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Markdown ne contient pas %q:\n%s", expected, output)
+		}
+	}
+}
+
+// failingWriter refuse toute écriture
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disque plein") }
+
+func TestWriteMarkdownError(t *testing.T) {
+	if err := Write(failingWriter{}, Markdown, testMeals); err == nil {
+		t.Error("Write() sans erreur, attendu l'erreur de l'écriture")
+	}
+}
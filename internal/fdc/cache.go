@@ -0,0 +1,68 @@
+package fdc
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSearchTTL est la durée de conservation par défaut des résultats de
+// recherche dans le cache
+const DefaultSearchTTL = 24 * time.Hour
+
+// Cache conserve les réponses brutes de l'API FDC. Le détail d'un aliment
+// est conservé sans limite de durée, un résultat de recherche pendant la
+// durée Options.SearchTTL. Les méthodes de lecture renvoient nil si la
+// réponse n'est pas en cache.
+type Cache interface {
+	CachedFood(fdcID int) ([]byte, error)
+	StoreFood(fdcID int, data []byte) error
+	CachedSearch(key string, maxAge time.Duration) ([]byte, error)
+	StoreSearch(key string, data []byte) error
+}
+
+// CacheStats compte les accès au cache depuis la création du client
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type cacheCounters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats renvoie le nombre de réponses servies par le cache et le nombre
+// de requêtes envoyées à l'API faute de réponse en cache
+func (c *Client) CacheStats() CacheStats {
+	return CacheStats{Hits: c.counters.hits.Load(), Misses: c.counters.misses.Load()}
+}
+
+// cached renvoie la réponse en cache lue par lookup, ou nil. Une erreur du
+// cache n'empêche pas la requête : elle est journalisée et l'API est
+// interrogée.
+func (c *Client) cached(lookup func() ([]byte, error)) []byte {
+	if c.cache == nil {
+		return nil
+	}
+	data, err := lookup()
+	if err != nil {
+		log.Printf("Erreur de lecture du cache FDC: %v", err)
+		return nil
+	}
+	if data == nil {
+		c.counters.misses.Add(1)
+		return nil
+	}
+	c.counters.hits.Add(1)
+	return data
+}
+
+func (c *Client) store(save func() error) {
+	if c.cache == nil {
+		return
+	}
+	if err := save(); err != nil {
+		log.Printf("Erreur d'écriture du cache FDC: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package fdc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCache est un cache en mémoire pour les tests
+type memoryCache struct {
+	mu       sync.Mutex
+	foods    map[int][]byte
+	searches map[string][]byte
+	maxAge   time.Duration
+	err      error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{foods: map[int][]byte{}, searches: map[string][]byte{}}
+}
+
+func (m *memoryCache) CachedFood(fdcID int) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.foods[fdcID], m.err
+}
+
+func (m *memoryCache) StoreFood(fdcID int, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.foods[fdcID] = data
+	return m.err
+}
+
+func (m *memoryCache) CachedSearch(key string, maxAge time.Duration) ([]byte, error) {
+	m.maxAge = maxAge
+	return m.searches[key], m.err
+}
+
+func (m *memoryCache) StoreSearch(key string, data []byte) error {
+	m.searches[key] = data
+	return m.err
+}
+
+func TestClientCache(t *testing.T) {
+	tests := []struct {
+		name             string
+		cacheErr         error
+		expectedRequests int
+		expectedStats    CacheStats
+	}{
+		{name: "Réponses servies par le cache", expectedRequests: 3, expectedStats: CacheStats{Hits: 3, Misses: 3}},
+		{name: "Cache indisponible", cacheErr: errors.New("connexion refusée"), expectedRequests: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/foods/search":
+					w.Write([]byte(`{"foods": [{"fdcId": 1, "description": "Apple"}]}`))
+				default:
+					w.Write([]byte(`{"fdcId": 1, "description": "Apple", "foodNutrients": [{"nutrientId": 1003, "value": 0.3}]}`))
+				}
+			}))
+			defer server.Close()
+
+			cache := newMemoryCache()
+			cache.err = tt.cacheErr
+			client := NewClientWithOptions("test-key", Options{BaseURL: server.URL, Cache: cache, SearchTTL: time.Hour})
+
+			for i := 0; i < 2; i++ {
+				food, err := client.GetFood(1)
+				if err != nil || food.Description != "Apple" {
+					t.Fatalf("GetFood() = %v, %v", food, err)
+				}
+				if proteins, _, _, _, _ := food.GetMacros(); proteins != 0.3 {
+					t.Errorf("Protéines = %v, attendu 0.3", proteins)
+				}
+				if _, err := client.GetFood(2); err != nil {
+					t.Fatalf("GetFood() erreur = %v", err)
+				}
+				result, err := client.SearchFoods("apple")
+				if err != nil || len(result.Foods) != 1 {
+					t.Fatalf("SearchFoods() = %v, %v", result, err)
+				}
+			}
+
+			if requests != tt.expectedRequests {
+				t.Errorf("%d requêtes, attendu %d", requests, tt.expectedRequests)
+			}
+			if stats := client.CacheStats(); stats != tt.expectedStats {
+				t.Errorf("CacheStats() = %+v, attendu %+v", stats, tt.expectedStats)
+			}
+			if cache.maxAge != time.Hour {
+				t.Errorf("Durée de validité des recherches = %v, attendu 1h", cache.maxAge)
+			}
+		})
+	}
+}
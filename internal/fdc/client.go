@@ -1,239 +1,548 @@
 package fdc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultBaseURL est l'adresse de l'API FoodData Central
+	DefaultBaseURL = "https://api.nal.usda.gov/fdc/v1"
+	// DefaultTimeout est la durée maximale d'une requête à l'API
+	DefaultTimeout = 10 * time.Second
 )
 
+// Options configure un client FDC. Les valeurs nulles sont remplacées par
+// les valeurs par défaut.
+type Options struct {
+	BaseURL string
+	Timeout time.Duration
+	// MaxRetries est le nombre de nouvelles tentatives après une erreur
+	// temporaire (429, 5xx ou erreur réseau) ; -1 pour n'en faire aucune
+	MaxRetries int
+	// Backoff est le délai avant la première nouvelle tentative, doublé à
+	// chaque tentative suivante
+	Backoff time.Duration
+	// MaxRetryWait est le délai maximal entre deux tentatives : au-delà,
+	// notamment si Retry-After l'exige, l'erreur est renvoyée immédiatement
+	MaxRetryWait time.Duration
+	// MaxElapsed est la durée maximale d'un appel, nouvelles tentatives
+	// comprises ; par défaut deux fois Timeout
+	MaxElapsed time.Duration
+	// Cache, s'il est défini, conserve les aliments et les recherches pour
+	// éviter de nouvelles requêtes à l'API
+	Cache     Cache
+	SearchTTL time.Duration
+}
+
 type Client struct {
-	apiKey string
-	client *http.Client
+	apiKey       string
+	baseURL      string
+	client       *http.Client
+	maxRetries   int
+	backoff      time.Duration
+	maxRetryWait time.Duration
+	maxElapsed   time.Duration
+	cache        Cache
+	searchTTL    time.Duration
+	counters     cacheCounters
 }
 
 type SearchResponse struct {
-	Foods []Food `json:"foods"`
+	Foods       []Food `json:"foods"`
+	TotalHits   int    `json:"totalHits"`
+	CurrentPage int    `json:"currentPage"`
+	TotalPages  int    `json:"totalPages"`
 }
 
 type Food struct {
-	FdcID       int        `json:"fdcId"`
-	Description string     `json:"description"`
-	DataType    string     `json:"dataType"`
-	Nutrients   []Nutrient `json:"foodNutrients"`
+	FdcID               int           `json:"fdcId"`
+	Description         string        `json:"description"`
+	DataType            string        `json:"dataType"`
+	Nutrients           []Nutrient    `json:"foodNutrients"`
+	FoodCategory        FoodCategory  `json:"foodCategory"`
+	BrandedFoodCategory string        `json:"brandedFoodCategory"`
+	BrandOwner          string        `json:"brandOwner"`
+	GtinUpc             string        `json:"gtinUpc"`
+	Portions            []FoodPortion `json:"foodPortions"`
+	// Portion indiquée sur l'emballage des produits de marque
+	ServingSize              float64 `json:"servingSize"`
+	ServingSizeUnit          string  `json:"servingSizeUnit"`
+	HouseholdServingFullText string  `json:"householdServingFullText"`
+}
+
+// FoodCategory est la catégorie d'un aliment. L'API la renvoie sous forme
+// de chaîne dans les résultats de recherche et d'objet dans le détail d'un aliment.
+type FoodCategory struct {
+	Description string `json:"description"`
+}
+
+func (fc *FoodCategory) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		fc.Description = description
+		return nil
+	}
+
+	var category struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &category); err != nil {
+		return err
+	}
+	fc.Description = category.Description
+	return nil
+}
+
+// Category renvoie la catégorie de l'aliment, quel que soit son type de données
+func (f *Food) Category() string {
+	if f.FoodCategory.Description != "" {
+		return f.FoodCategory.Description
+	}
+	return f.BrandedFoodCategory
 }
 
 type Nutrient struct {
 	ID       int     `json:"nutrientId"`
+	Number   string  `json:"nutrientNumber"`
 	Name     string  `json:"nutrientName"`
 	Amount   float64 `json:"value"`
 	UnitName string  `json:"unitName"`
 	// Structure alternative pour l'API FDC v1
 	Nutrient struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
+		ID       int    `json:"id"`
+		Number   string `json:"number"`
+		Name     string `json:"name"`
+		UnitName string `json:"unitName"`
 	} `json:"nutrient"`
 	Value  float64 `json:"amount"`
 	Type   string  `json:"type"`
 }
 
-// GetNutrientValue récupère la valeur d'un nutriment par son ID
-func (f *Food) GetNutrientValue(nutrientIDs ...int) float64 {
-	for _, id := range nutrientIDs {
-		for _, n := range f.Nutrients {
-			// Format 1: Structure directe avec ID et Amount
-			if n.ID == id && n.Amount > 0 {
-				return n.Amount
-			}
-			
-			// Format 2: Structure avec nutrient.id et value/amount
-			if n.Nutrient.ID == id {
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
-			
-			// Recherche dans des structures alternatives possibles
-			// Ces adaptations permettent de gérer différentes versions de l'API
-			if n.Type == "FoodNutrient" && n.Nutrient.ID == id {
-				return n.Value
-			}
-		}
+// GetMacros renvoie les macronutriments pour 100g
+func (f *Food) GetMacros() (proteins, carbs, fats, calories, fiber float64) {
+	vector := f.NutrientVector()
+	proteins = vector[Proteins]
+	carbs = vector[Carbs]
+	fats = vector[Fats]
+	calories = vector[Calories]
+	fiber = vector[Fiber]
+	return
+}
+
+// MacrosFor calcule les macros pour une quantité donnée en grammes,
+// les valeurs FDC étant exprimées pour 100g
+func (f *Food) MacrosFor(amount float64) (proteins, carbs, fats, calories, fiber float64) {
+	proteins, carbs, fats, calories, fiber = f.GetMacros()
+
+	ratio := amount / 100
+	return math.Max(proteins, 0) * ratio,
+		math.Max(carbs, 0) * ratio,
+		math.Max(fats, 0) * ratio,
+		math.Max(calories, 0) * ratio,
+		math.Max(fiber, 0) * ratio
+}
+
+func NewClient(apiKey string) *Client {
+	return NewClientWithOptions(apiKey, Options{})
+}
+
+// NewClientWithBaseURL crée un client pour une autre adresse que l'API
+// publique, par exemple un miroir ou un serveur de test
+func NewClientWithBaseURL(apiKey, baseURL string) *Client {
+	return NewClientWithOptions(apiKey, Options{BaseURL: baseURL})
+}
+
+func NewClientWithOptions(apiKey string, opts Options) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
 	}
-	
-	// Si rien n'est trouvé, parcourir à nouveau et chercher par nom
-	for _, n := range f.Nutrients {
-		for _, id := range nutrientIDs {
-			// Correspondances de noms pour les nutriments courants
-			if (id == 1003 || id == 203) && (containsIgnoreCase(n.Name, "protein") || 
-			    containsIgnoreCase(n.Nutrient.Name, "protein")) {
-				if n.Amount > 0 {
-					return n.Amount
-				}
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
-			
-			if (id == 1005 || id == 205) && (containsIgnoreCase(n.Name, "carbohydrate") || 
-			    containsIgnoreCase(n.Nutrient.Name, "carbohydrate")) {
-				if n.Amount > 0 {
-					return n.Amount
-				}
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
-			
-			if (id == 1004 || id == 204) && (containsIgnoreCase(n.Name, "fat") || 
-			    containsIgnoreCase(n.Nutrient.Name, "fat")) {
-				if n.Amount > 0 {
-					return n.Amount
-				}
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
-			
-			if (id == 1008 || id == 208) && (containsIgnoreCase(n.Name, "energy") || 
-			    containsIgnoreCase(n.Nutrient.Name, "energy")) {
-				if n.Amount > 0 {
-					return n.Amount
-				}
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
-			
-			if (id == 1079 || id == 291) && (containsIgnoreCase(n.Name, "fiber") || 
-			    containsIgnoreCase(n.Nutrient.Name, "fiber")) {
-				if n.Amount > 0 {
-					return n.Amount
-				}
-				if n.Value > 0 {
-					return n.Value
-				}
-			}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 500 * time.Millisecond
+	}
+	if opts.MaxRetryWait <= 0 {
+		opts.MaxRetryWait = 30 * time.Second
+	}
+	if opts.MaxElapsed <= 0 {
+		opts.MaxElapsed = 2 * opts.Timeout
+	}
+	if opts.SearchTTL <= 0 {
+		opts.SearchTTL = DefaultSearchTTL
+	}
+
+	return &Client{
+		apiKey:       apiKey,
+		baseURL:      strings.TrimRight(opts.BaseURL, "/"),
+		client:       &http.Client{Timeout: opts.Timeout},
+		maxRetries:   max(opts.MaxRetries, 0),
+		backoff:      opts.Backoff,
+		maxRetryWait: opts.MaxRetryWait,
+		maxElapsed:   opts.MaxElapsed,
+		cache:        opts.Cache,
+		searchTTL:    opts.SearchTTL,
+	}
+}
+
+func (c *Client) SearchFoods(query string) (*SearchResponse, error) {
+	return c.SearchFoodsContext(context.Background(), query)
+}
+
+func (c *Client) SearchFoodsContext(ctx context.Context, query string) (*SearchResponse, error) {
+	return c.SearchFoodsWithOptions(ctx, query, SearchOptions{})
+}
+
+// SearchFoodsWithOptions recherche des aliments avec pagination, filtres et
+// tri. Les options invalides renvoient une erreur équivalente à
+// ErrInvalidSearch, sans requête vers l'API.
+func (c *Client) SearchFoodsWithOptions(ctx context.Context, query string, opts SearchOptions) (*SearchResponse, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	params := opts.params(query)
+	key := searchCacheKey(params)
+	body := c.cached(func() ([]byte, error) { return c.cache.CachedSearch(key, c.searchTTL) })
+	if body == nil {
+		var err error
+		if body, err = c.fetch(ctx, "/foods/search", params); err != nil {
+			return nil, err
 		}
+		c.store(func() error { return c.cache.StoreSearch(key, body) })
+	}
+
+	var result SearchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
 	}
-	
-	return 0
+	return &result, nil
 }
 
-// Fonction utilitaire pour vérifier si une chaîne contient une sous-chaîne (insensible à la casse)
-func containsIgnoreCase(s, substr string) bool {
-	if s == "" || substr == "" {
-		return false
+// CachedSearchWithOptions renvoie le résultat d'une recherche s'il figure
+// dans le cache, sans interroger l'API, ou nil
+func (c *Client) CachedSearchWithOptions(query string, opts SearchOptions) *SearchResponse {
+	if c.cache == nil || opts.Validate() != nil {
+		return nil
+	}
+	body, err := c.cache.CachedSearch(searchCacheKey(opts.params(query)), c.searchTTL)
+	if err != nil {
+		log.Printf("Erreur de lecture du cache FDC: %v", err)
+		return nil
 	}
-	
-	// Conversion simple en minuscules pour la comparaison
-	s = strings.ToLower(s)
-	substr = strings.ToLower(substr)
-	return strings.Contains(s, substr)
+	var result SearchResponse
+	if body == nil || json.Unmarshal(body, &result) != nil {
+		return nil
+	}
+	return &result
 }
 
-func (f *Food) GetMacros() (proteins, carbs, fats, calories, fiber float64) {
-	const (
-		ProteinID  = 1003 // Protein
-		CarbID     = 1005 // Carbohydrates
-		FatID      = 1004 // Total lipids (fat)
-		CalorieID  = 1008 // Energy (kcal)
-		FiberID    = 1079 // Fiber, total dietary
-		
-		ProteinID2  = 203 // Protein (ancien ID)
-		CarbID2     = 205 // Carbohydrates (ancien ID)
-		FatID2      = 204 // Total lipids (fat) (ancien ID)
-		CalorieID2  = 208 // Energy (kcal) (ancien ID)
-		FiberID2    = 291 // Fiber, total dietary (ancien ID)
-	)
-
-	proteins = f.GetNutrientValue(ProteinID, ProteinID2)
-	carbs = f.GetNutrientValue(CarbID, CarbID2)
-	fats = f.GetNutrientValue(FatID, FatID2)
-	calories = f.GetNutrientValue(CalorieID, CalorieID2)
-	fiber = f.GetNutrientValue(FiberID, FiberID2)
-
-	if proteins == 0 && carbs == 0 && fats == 0 && calories == 0 {
-		fmt.Printf("Aucune valeur nutritionnelle trouvée pour: %s\n", f.Description)
-		if len(f.Nutrients) > 0 {
-			// Ajouter des logs plus détaillés sur la structure des nutriments
-			fmt.Printf("Premier nutriment: ID=%d, Name=%s, Valeur=%f\n", 
-				f.Nutrients[0].ID, f.Nutrients[0].Name, f.Nutrients[0].Amount)
-			fmt.Printf("Structure du premier nutriment: %+v\n", f.Nutrients[0])
-		}
-	}
-	
-	return
+// searchCacheKey renvoie la clé du cache d'une recherche, qui reprend tous
+// ses paramètres
+func searchCacheKey(params url.Values) string {
+	return "/foods/search?" + params.Encode()
 }
 
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
-		client: &http.Client{},
+func (c *Client) GetFood(fdcID int) (*Food, error) {
+	return c.GetFoodContext(context.Background(), fdcID)
+}
+
+// GetFoodContext récupère le détail d'un aliment, depuis le cache s'il y
+// figure. Renvoie une erreur équivalente à ErrNotFound si l'identifiant est
+// inconnu de FDC.
+func (c *Client) GetFoodContext(ctx context.Context, fdcID int) (*Food, error) {
+	body := c.cached(func() ([]byte, error) { return c.cache.CachedFood(fdcID) })
+	if body == nil {
+		params := url.Values{}
+		params.Add("format", "full")
+
+		var err error
+		if body, err = c.fetch(ctx, fmt.Sprintf("/food/%d", fdcID), params); err != nil {
+			return nil, err
+		}
+		c.store(func() error { return c.cache.StoreFood(fdcID, body) })
 	}
+
+	var food Food
+	if err := json.Unmarshal(body, &food); err != nil {
+		return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
+	}
+	return &food, nil
 }
 
-func (c *Client) SearchFoods(query string) (*SearchResponse, error) {
-	baseURL := "https://api.nal.usda.gov/fdc/v1/foods/search"
-	
-	params := url.Values{}
-	params.Add("api_key", c.apiKey)
-	params.Add("query", query)
-	params.Add("dataType", "Foundation,SR Legacy")
-	
-	resp, err := c.client.Get(baseURL + "?" + params.Encode())
-	if err != nil {
+// maxFoodsPerRequest est le nombre maximal d'identifiants acceptés par
+// l'API pour une requête POST /foods
+const maxFoodsPerRequest = 20
+
+// maxConcurrentBatches est le nombre maximal de lots demandés en parallèle
+const maxConcurrentBatches = 4
+
+func (c *Client) GetFoods(fdcIDs []int) ([]Food, error) {
+	return c.GetFoodsContext(context.Background(), fdcIDs)
+}
+
+// GetFoodsContext récupère le détail de plusieurs aliments. Ceux absents du
+// cache sont demandés par lots de 20 avec POST /foods, plutôt qu'une requête
+// par aliment, et au plus maxConcurrentBatches lots à la fois. Les aliments
+// sont renvoyés dans l'ordre des identifiants, sans doublon ; ceux inconnus
+// de FDC sont omis.
+func (c *Client) GetFoodsContext(ctx context.Context, fdcIDs []int) ([]Food, error) {
+	found := make(map[int]Food, len(fdcIDs))
+	var missing []int
+	for _, id := range uniqueIDs(fdcIDs) {
+		body := c.cached(func() ([]byte, error) { return c.cache.CachedFood(id) })
+		if body == nil {
+			missing = append(missing, id)
+			continue
+		}
+		var food Food
+		if err := json.Unmarshal(body, &food); err != nil {
+			return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
+		}
+		found[id] = food
+	}
+
+	// Chaque lot écrit dans sa propre case, lue une fois tous les lots reçus
+	batches := make([][]Food, (len(missing)+maxFoodsPerRequest-1)/maxFoodsPerRequest)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentBatches)
+	for i := range batches {
+		i := i
+		start := i * maxFoodsPerRequest
+		ids := missing[start:min(start+maxFoodsPerRequest, len(missing))]
+		group.Go(func() error {
+			foods, err := c.fetchFoods(groupCtx, ids)
+			batches[i] = foods
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	for _, batch := range batches {
+		for _, food := range batch {
+			found[food.FdcID] = food
+		}
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	foods := make([]Food, 0, len(found))
+	for _, id := range uniqueIDs(fdcIDs) {
+		if food, ok := found[id]; ok {
+			foods = append(foods, food)
+		}
 	}
+	return foods, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// fetchFoods demande un lot d'aliments avec POST /foods et les met en cache
+func (c *Client) fetchFoods(ctx context.Context, fdcIDs []int) ([]Food, error) {
+	payload, err := json.Marshal(map[string]interface{}{"fdcIds": fdcIDs, "format": "full"})
 	if err != nil {
-		return nil, fmt.Errorf("erreur lors de la lecture du corps de la réponse: %v", err)
+		return nil, err
 	}
-	
-	var result SearchResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	body, err := c.send(ctx, http.MethodPost, "/foods", nil, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chaque aliment est conservé tel que reçu pour le cache
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
 		return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
 	}
+	foods := make([]Food, 0, len(items))
+	for _, item := range items {
+		var food Food
+		if err := json.Unmarshal(item, &food); err != nil {
+			return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
+		}
+		c.store(func() error { return c.cache.StoreFood(food.FdcID, item) })
+		foods = append(foods, food)
+	}
+	return foods, nil
+}
 
-	return &result, nil
+// uniqueIDs renvoie les identifiants sans doublon, dans leur ordre d'origine
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
 }
 
-func (c *Client) GetFood(fdcID int) (*Food, error) {
-	baseURL := fmt.Sprintf("https://api.nal.usda.gov/fdc/v1/food/%d", fdcID)
-	
-	params := url.Values{}
-	params.Add("api_key", c.apiKey)
-	params.Add("format", "full")
-	
-	resp, err := c.client.Get(baseURL + "?" + params.Encode())
+// fetch effectue une requête GET vers l'API et renvoie le corps de la
+// réponse
+func (c *Client) fetch(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.send(ctx, http.MethodGet, path, params, nil)
+}
+
+// send effectue une requête vers l'API, avec un corps JSON s'il n'est pas
+// nil, et renvoie le corps de la réponse. Les erreurs temporaires sont
+// retentées avec un délai exponentiel, ou le délai indiqué par l'en-tête
+// Retry-After, tant que la durée totale ne dépasse pas maxElapsed.
+func (c *Client) send(ctx context.Context, method, path string, params url.Values, payload []byte) ([]byte, error) {
+	requestURL := c.baseURL + path
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.maxElapsed)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		body, err := c.do(ctx, method, requestURL, payload)
+		if err == nil {
+			return body, nil
+		}
+
+		wait, retry := c.retryDelay(ctx, err, attempt)
+		if !retry {
+			return nil, err
+		}
+		// Inutile d'attendre si la tentative suivante dépasserait le délai total
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// do effectue une seule requête et renvoie le corps d'une réponse 200,
+// ou une *APIError pour tout autre statut
+func (c *Client) do(ctx context.Context, method, requestURL string, payload []byte) ([]byte, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	// La clé est passée en en-tête pour ne pas figurer dans l'URL, reprise
+	// par les messages d'erreur réseau
+	req.Header.Set("X-Api-Key", c.apiKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la lecture du corps de la réponse: %v", err)
 	}
-	
-	var food Food
-	if err := json.Unmarshal(body, &food); err != nil {
-		return nil, fmt.Errorf("erreur lors de la désérialisation: %v", err)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
+	return body, nil
+}
 
-	return &food, nil
+// retryDelay indique si une requête en erreur doit être retentée, et après
+// quel délai
+func (c *Client) retryDelay(ctx context.Context, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.maxRetries || ctx.Err() != nil {
+		return 0, false
+	}
+
+	wait := c.backoff << attempt
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if !apiErr.temporary() {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			// Inutile d'attendre si l'API demande un délai trop long
+			if apiErr.RetryAfter > c.maxRetryWait {
+				return 0, false
+			}
+			wait = apiErr.RetryAfter
+		}
+	}
+	return min(wait, c.maxRetryWait), true
+}
+
+// ValidBarcode indique si code est un code-barres GTIN/UPC plausible
+// (8 à 14 chiffres)
+func ValidBarcode(code string) bool {
+	if len(code) < 8 || len(code) > 14 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sameBarcode compare deux codes GTIN/UPC en ignorant les zéros initiaux,
+// un UPC-A à 12 chiffres et son équivalent EAN-13 désignant le même produit
+func sameBarcode(a, b string) bool {
+	a = strings.TrimLeft(strings.TrimSpace(a), "0")
+	b = strings.TrimLeft(strings.TrimSpace(b), "0")
+	return a != "" && a == b
+}
+
+func (c *Client) GetFoodByBarcode(code string) (*Food, error) {
+	return c.GetFoodByBarcodeContext(context.Background(), code)
+}
+
+// GetFoodByBarcodeContext recherche un produit de marque (Branded Foods) par
+// son code-barres GTIN/UPC. Renvoie ErrNotFound si aucun produit ne correspond.
+func (c *Client) GetFoodByBarcodeContext(ctx context.Context, code string) (*Food, error) {
+	result, err := c.SearchFoodsWithOptions(ctx, code, SearchOptions{DataTypes: []string{"Branded"}})
+	if err != nil {
+		return nil, err
+	}
+
+	// La recherche plein texte peut renvoyer des produits proches : seul un
+	// code identique est retenu
+	for i := range result.Foods {
+		food := &result.Foods[i]
+		if !sameBarcode(food.GtinUpc, code) {
+			continue
+		}
+		if len(food.Nutrients) == 0 {
+			return c.GetFoodContext(ctx, food.FdcID)
+		}
+		return food, nil
+	}
+
+	return nil, ErrNotFound
 }
@@ -1,9 +1,18 @@
 package fdc
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestSearchFoods(t *testing.T) {
@@ -12,10 +21,13 @@ func TestSearchFoods(t *testing.T) {
 			t.Errorf("Expected path '/fdc/v1/foods/search', got %s", r.URL.Path)
 		}
 
-		apiKey := r.URL.Query().Get("api_key")
+		apiKey := r.Header.Get("X-Api-Key")
 		if apiKey != "test-key" {
 			t.Errorf("Expected API key 'test-key', got %s", apiKey)
 		}
+		if r.URL.Query().Has("api_key") {
+			t.Errorf("La clé API ne doit pas figurer dans l'URL: %s", r.URL)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{
@@ -34,10 +46,7 @@ func TestSearchFoods(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := &Client{
-		apiKey: "test-key",
-		client: server.Client(),
-	}
+	client := NewClientWithBaseURL("test-key", server.URL+"/fdc/v1")
 
 	resp, err := client.SearchFoods("test")
 	if err != nil {
@@ -63,10 +72,13 @@ func TestGetFood(t *testing.T) {
 			t.Errorf("Expected path '/fdc/v1/food/123456', got %s", r.URL.Path)
 		}
 
-		apiKey := r.URL.Query().Get("api_key")
+		apiKey := r.Header.Get("X-Api-Key")
 		if apiKey != "test-key" {
 			t.Errorf("Expected API key 'test-key', got %s", apiKey)
 		}
+		if r.URL.Query().Has("api_key") {
+			t.Errorf("La clé API ne doit pas figurer dans l'URL: %s", r.URL)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{
@@ -81,10 +93,7 @@ func TestGetFood(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := &Client{
-		apiKey: "test-key",
-		client: server.Client(),
-	}
+	client := NewClientWithBaseURL("test-key", server.URL+"/fdc/v1")
 
 	food, err := client.GetFood(123456)
 	if err != nil {
@@ -99,3 +108,407 @@ func TestGetFood(t *testing.T) {
 		t.Errorf("Expected description 'Test Food', got %s", food.Description)
 	}
 }
+
+func TestFoodCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "Chaîne dans les résultats de recherche",
+			body:     `{"fdcId": 1, "foodCategory": "Vegetables and Vegetable Products"}`,
+			expected: "Vegetables and Vegetable Products",
+		},
+		{
+			name:     "Objet dans le détail d'un aliment",
+			body:     `{"fdcId": 1, "foodCategory": {"id": 11, "code": "1100", "description": "Vegetables and Vegetable Products"}}`,
+			expected: "Vegetables and Vegetable Products",
+		},
+		{
+			name:     "Catégorie d'un produit de marque",
+			body:     `{"fdcId": 1, "brandedFoodCategory": "Cheese"}`,
+			expected: "Cheese",
+		},
+		{
+			name:     "Sans catégorie",
+			body:     `{"fdcId": 1, "foodCategory": null}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var food Food
+			if err := json.Unmarshal([]byte(tt.body), &food); err != nil {
+				t.Fatalf("Unmarshal() erreur = %v", err)
+			}
+			if food.Category() != tt.expected {
+				t.Errorf("Category() = %q, attendu %q", food.Category(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetFoodByBarcode(t *testing.T) {
+	const searchBody = `{
+		"foods": [
+			{
+				"fdcId": 111,
+				"description": "Cereal bar, chocolate",
+				"dataType": "Branded",
+				"gtinUpc": "0041570054161",
+				"brandOwner": "Other Brand",
+				"foodNutrients": [{"nutrientId": 1003, "value": 4}]
+			},
+			{
+				"fdcId": 222,
+				"description": "Almond milk",
+				"dataType": "Branded",
+				"gtinUpc": "041570054165",
+				"brandOwner": "Blue Diamond",
+				"foodNutrients": [
+					{"nutrientId": 1003, "nutrientName": "Protein", "value": 0.4},
+					{"nutrientId": 1008, "nutrientName": "Energy", "value": 13}
+				]
+			},
+			{
+				"fdcId": 333,
+				"description": "Greek yogurt",
+				"dataType": "Branded",
+				"gtinUpc": "00818290012345"
+			}
+		]
+	}`
+
+	tests := []struct {
+		name       string
+		code       string
+		expectedID int
+		wantErr    error
+	}{
+		{name: "Code UPC-A identique", code: "041570054165", expectedID: 222},
+		{name: "Code EAN-13 avec zéro initial", code: "0041570054165", expectedID: 222},
+		{name: "Détail récupéré si la recherche n'a pas de nutriments", code: "818290012345", expectedID: 333},
+		{name: "Code absent des résultats", code: "12345678", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/foods/search":
+					if got := r.URL.Query().Get("dataType"); got != "Branded" {
+						t.Errorf("dataType = %q, attendu Branded", got)
+					}
+					if got := r.URL.Query().Get("query"); got != tt.code {
+						t.Errorf("query = %q, attendu %q", got, tt.code)
+					}
+					w.Write([]byte(searchBody))
+				case "/food/333":
+					w.Write([]byte(`{"fdcId": 333, "description": "Greek yogurt", "foodNutrients": [{"nutrient": {"id": 1003}, "amount": 9}]}`))
+				default:
+					t.Errorf("Chemin inattendu: %s", r.URL.Path)
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClientWithBaseURL("test-key", server.URL)
+			food, err := client.GetFoodByBarcode(tt.code)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetFoodByBarcode(%q) erreur = %v, attendu %v", tt.code, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFoodByBarcode(%q) erreur inattendue: %v", tt.code, err)
+			}
+			if food.FdcID != tt.expectedID {
+				t.Errorf("FdcID = %d, attendu %d", food.FdcID, tt.expectedID)
+			}
+			if proteins, _, _, _, _ := food.GetMacros(); proteins <= 0 {
+				t.Errorf("Protéines = %v, valeur positive attendue", proteins)
+			}
+		})
+	}
+}
+
+func TestValidBarcode(t *testing.T) {
+	tests := map[string]bool{
+		"041570054165":    true,
+		"12345678":        true,
+		"00818290012345":  true,
+		"1234567":         false,
+		"123456789012345": false,
+		"04157005416A":    false,
+		"":                false,
+	}
+
+	for code, expected := range tests {
+		if got := ValidBarcode(code); got != expected {
+			t.Errorf("ValidBarcode(%q) = %v, attendu %v", code, got, expected)
+		}
+	}
+}
+
+func TestGetFoodRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		wantErr      error
+		wantStatus   int
+		wantRequests int
+	}{
+		{name: "Erreur serveur temporaire", statuses: []int{503, 502, 200}, wantRequests: 3},
+		{name: "Limite atteinte puis succès", statuses: []int{429, 200}, wantRequests: 2},
+		{name: "Erreur serveur persistante", statuses: []int{500}, wantStatus: 500, wantRequests: 3},
+		{name: "Retry-After trop long", statuses: []int{429}, retryAfter: "120", wantErr: ErrRateLimited, wantRequests: 1},
+		{name: "Aliment inconnu", statuses: []int{404}, wantErr: ErrNotFound, wantRequests: 1},
+		{name: "Clé API invalide", statuses: []int{403}, wantErr: ErrUnauthorized, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[min(requests, len(tt.statuses)-1)]
+				requests++
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{"fdcId": 123456, "description": "Test Food"}`))
+				}
+			}))
+			defer server.Close()
+
+			client := NewClientWithOptions("test-key", Options{
+				BaseURL:      server.URL,
+				MaxRetries:   2,
+				Backoff:      time.Millisecond,
+				MaxRetryWait: 10 * time.Millisecond,
+			})
+			food, err := client.GetFood(123456)
+
+			if requests != tt.wantRequests {
+				t.Errorf("%d requêtes, attendu %d", requests, tt.wantRequests)
+			}
+			if tt.wantStatus != 0 {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Fatalf("GetFood() erreur = %v, attendu une erreur %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if tt.wantErr == nil {
+				if err != nil || food.FdcID != 123456 {
+					t.Fatalf("GetFood() = %v, %v, attendu l'aliment 123456", food, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetFood() erreur = %v, attendu %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetFoodContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-key", Options{BaseURL: server.URL, Backoff: time.Hour, MaxRetryWait: time.Hour, MaxElapsed: 2 * time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	if _, err := client.GetFoodContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetFoodContext() erreur = %v, attendu %v", err, context.Canceled)
+	}
+
+	// Sans annulation, l'attente ne dépasse pas le délai total
+	client = NewClientWithOptions("test-key", Options{BaseURL: server.URL, Backoff: time.Hour, MaxRetryWait: time.Hour, MaxElapsed: 20 * time.Millisecond})
+	_, err := client.GetFoodContext(context.Background(), 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GetFoodContext() erreur = %v, attendu l'erreur 503 de l'API", err)
+	}
+}
+
+func TestGetFoodTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-key", Options{BaseURL: server.URL, Timeout: 10 * time.Millisecond, MaxRetries: -1})
+	if _, err := client.GetFood(1); err == nil {
+		t.Error("GetFood() sans erreur, attendu un dépassement du délai")
+	}
+}
+
+func TestGetFoodMaxElapsed(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions("test-key", Options{
+		BaseURL:    server.URL,
+		Timeout:    30 * time.Millisecond,
+		MaxRetries: 10,
+		Backoff:    time.Millisecond,
+		MaxElapsed: 70 * time.Millisecond,
+	})
+	start := time.Now()
+	if _, err := client.GetFood(1); err == nil {
+		t.Fatal("GetFood() sans erreur, attendu un dépassement du délai")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("GetFood() a duré %v, attendu au plus MaxElapsed", elapsed)
+	}
+	if n := requests.Load(); n > 3 {
+		t.Errorf("%d requêtes, attendu au plus 3", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"30":                            30 * time.Second,
+		"Fri, 15 Mar 2024 12:01:00 GMT": time.Minute,
+		"Fri, 15 Mar 2024 11:00:00 GMT": 0,
+		"bientôt":                       0,
+	}
+
+	for value, expected := range tests {
+		if got := parseRetryAfter(value, now); got != expected {
+			t.Errorf("parseRetryAfter(%q) = %v, attendu %v", value, got, expected)
+		}
+	}
+}
+
+func TestGetFoods(t *testing.T) {
+	ids := func(from, to int) []int {
+		var ids []int
+		for id := from; id <= to; id++ {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name        string
+		ids         []int
+		cached      []int
+		failFirst   bool
+		wantBatches [][]int
+		want        []int
+	}{
+		{name: "Un seul lot", ids: ids(1, 5), wantBatches: [][]int{ids(1, 5)}, want: ids(1, 5)},
+		{name: "Plusieurs lots", ids: ids(1, 45), wantBatches: [][]int{ids(1, 20), ids(21, 40), ids(41, 45)}, want: ids(1, 45)},
+		{name: "Plus de lots que de requêtes parallèles", ids: ids(1, 200), wantBatches: [][]int{ids(1, 20), ids(21, 40), ids(41, 60), ids(61, 80), ids(81, 100), ids(101, 120), ids(121, 140), ids(141, 160), ids(161, 180), ids(181, 200)}, want: ids(1, 200)},
+		{name: "Doublons et aliment inconnu", ids: []int{3, 1, 3, 999}, wantBatches: [][]int{{3, 1, 999}}, want: []int{3, 1}},
+		{name: "Aliments en cache", ids: ids(1, 3), cached: []int{1, 3}, wantBatches: [][]int{{2}}, want: ids(1, 3)},
+		{name: "Tous en cache", ids: ids(1, 2), cached: ids(1, 2), want: ids(1, 2)},
+		{name: "Erreur temporaire", ids: ids(1, 2), failFirst: true, wantBatches: [][]int{ids(1, 2), ids(1, 2)}, want: ids(1, 2)},
+		{name: "Aucun identifiant", ids: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var batches [][]int
+			var inFlight, peak int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				inFlight++
+				peak = max(peak, inFlight)
+				mu.Unlock()
+				defer func() {
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+				}()
+				// Laisse aux autres lots le temps d'arriver
+				time.Sleep(5 * time.Millisecond)
+
+				if r.Method != http.MethodPost || r.URL.Path != "/foods" || r.Header.Get("X-Api-Key") != "test-key" {
+					t.Errorf("requête inattendue: %s %s", r.Method, r.URL)
+				}
+				var payload struct {
+					FdcIDs []int  `json:"fdcIds"`
+					Format string `json:"format"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Format != "full" {
+					t.Errorf("corps invalide: %+v, %v", payload, err)
+				}
+				mu.Lock()
+				batches = append(batches, payload.FdcIDs)
+				first := len(batches) == 1
+				mu.Unlock()
+				if tt.failFirst && first {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				var foods []Food
+				for _, id := range payload.FdcIDs {
+					// Les identifiants inconnus sont absents de la réponse
+					if id != 999 {
+						foods = append(foods, Food{FdcID: id, Description: "Aliment"})
+					}
+				}
+				json.NewEncoder(w).Encode(foods)
+			}))
+			defer server.Close()
+
+			cache := newMemoryCache()
+			for _, id := range tt.cached {
+				cache.foods[id] = []byte(`{"fdcId": ` + strconv.Itoa(id) + `, "description": "En cache"}`)
+			}
+			client := NewClientWithOptions("test-key", Options{
+				BaseURL: server.URL,
+				Cache:   cache,
+				Backoff: time.Millisecond,
+			})
+
+			foods, err := client.GetFoods(tt.ids)
+			if err != nil {
+				t.Fatalf("GetFoods() erreur: %v", err)
+			}
+			// Les lots arrivent dans un ordre quelconque
+			sort.Slice(batches, func(i, j int) bool { return batches[i][0] < batches[j][0] })
+			if !reflect.DeepEqual(batches, tt.wantBatches) {
+				t.Errorf("lots demandés = %v, attendu %v", batches, tt.wantBatches)
+			}
+			if peak > maxConcurrentBatches {
+				t.Errorf("%d lots demandés en parallèle, attendu au plus %d", peak, maxConcurrentBatches)
+			}
+
+			var got []int
+			for _, food := range foods {
+				got = append(got, food.FdcID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aliments = %v, attendu %v", got, tt.want)
+			}
+
+			// Les aliments reçus sont mis en cache
+			for _, id := range tt.want {
+				if cache.foods[id] == nil {
+					t.Errorf("aliment %d absent du cache", id)
+				}
+			}
+		})
+	}
+}
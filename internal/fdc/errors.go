@@ -0,0 +1,68 @@
+package fdc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrNotFound est renvoyée lorsqu'aucun aliment ne correspond à la recherche
+	ErrNotFound = errors.New("aliment non trouvé")
+	// ErrRateLimited est renvoyée lorsque le quota de requêtes de la clé API
+	// est épuisé
+	ErrRateLimited = errors.New("limite de requêtes FDC atteinte")
+	// ErrUnauthorized est renvoyée lorsque la clé API est absente ou refusée
+	ErrUnauthorized = errors.New("clé API FDC refusée")
+	// ErrInvalidSearch est renvoyée lorsque les options d'une recherche sont
+	// invalides
+	ErrInvalidSearch = errors.New("recherche FDC invalide")
+)
+
+// APIError est une réponse en erreur de l'API FDC. errors.Is permet de la
+// comparer à ErrNotFound, ErrRateLimited et ErrUnauthorized.
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter est le délai demandé par l'API avant une nouvelle requête
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d %s - %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		// api.data.gov renvoie 403 pour une clé invalide
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// temporary indique si la requête peut être retentée
+func (e *APIError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// parseRetryAfter lit l'en-tête Retry-After, exprimé en secondes ou sous
+// forme de date HTTP
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
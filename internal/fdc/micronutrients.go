@@ -0,0 +1,31 @@
+package fdc
+
+// Micronutrients est la liste des micronutriments enregistrés pour chaque
+// aliment consommé, dans l'ordre d'affichage
+var Micronutrients = []NutrientDef{
+	{Key: Sodium, Label: "Sodium", Unit: "mg", Sources: []NutrientSource{{1093, "307"}}},
+	{Key: Sugars, Label: "Sucres", Unit: "g", Sources: []NutrientSource{{2000, "269"}, {1063, "269.3"}}},
+	{Key: SaturatedFat, Label: "Acides gras saturés", Unit: "g", Sources: []NutrientSource{{1258, "606"}}},
+	{Key: Cholesterol, Label: "Cholestérol", Unit: "mg", Sources: []NutrientSource{{1253, "601"}}},
+	{Key: Potassium, Label: "Potassium", Unit: "mg", Sources: []NutrientSource{{1092, "306"}}},
+	{Key: Calcium, Label: "Calcium", Unit: "mg", Sources: []NutrientSource{{1087, "301"}}},
+	{Key: Iron, Label: "Fer", Unit: "mg", Sources: []NutrientSource{{1089, "303"}}},
+	{Key: VitaminA, Label: "Vitamine A", Unit: "µg", Sources: []NutrientSource{{1106, "320"}}},
+	{Key: VitaminC, Label: "Vitamine C", Unit: "mg", Sources: []NutrientSource{{1162, "401"}}},
+	{Key: VitaminD, Label: "Vitamine D", Unit: "µg", Sources: []NutrientSource{{1114, "328"}}},
+	{Key: VitaminB12, Label: "Vitamine B12", Unit: "µg", Sources: []NutrientSource{{1178, "418"}}},
+}
+
+// MicronutrientsFor renvoie les micronutriments d'une quantité donnée en
+// grammes, indexés par NutrientDef.Key. Les micronutriments absents des
+// données FDC ne figurent pas dans le résultat.
+func (f *Food) MicronutrientsFor(amount float64) map[string]float64 {
+	vector := f.NutrientVector()
+	values := make(map[string]float64)
+	for _, m := range Micronutrients {
+		if value := vector[m.Key]; value > 0 {
+			values[string(m.Key)] = value * amount / 100
+		}
+	}
+	return values
+}
@@ -0,0 +1,61 @@
+package fdc
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestMicronutrientsFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		amount   float64
+		expected map[string]float64
+	}{
+		{
+			name: "Identifiants actuels",
+			body: `{"foodNutrients": [
+				{"nutrientId": 1093, "value": 400},
+				{"nutrientId": 2000, "value": 5},
+				{"nutrientId": 1162, "value": 53.2}
+			]}`,
+			amount:   50,
+			expected: map[string]float64{"sodium": 200, "sugars": 2.5, "vitamin_c": 26.6},
+		},
+		{
+			name: "Numéros de nutriments au format détaillé",
+			body: `{"foodNutrients": [
+				{"nutrient": {"number": "301"}, "amount": 120},
+				{"nutrient": {"number": "418"}, "amount": 0.5}
+			]}`,
+			amount:   200,
+			expected: map[string]float64{"calcium": 240, "vitamin_b12": 1},
+		},
+		{
+			name:     "Aucun micronutriment",
+			body:     `{"foodNutrients": [{"nutrientId": 1003, "value": 10}]}`,
+			amount:   100,
+			expected: map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var food Food
+			if err := json.Unmarshal([]byte(tt.body), &food); err != nil {
+				t.Fatalf("Unmarshal() erreur = %v", err)
+			}
+
+			got := food.MicronutrientsFor(tt.amount)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("MicronutrientsFor(%v) = %v, attendu %v", tt.amount, got, tt.expected)
+			}
+			for key, want := range tt.expected {
+				if math.Abs(got[key]-want) > 0.0001 {
+					t.Errorf("MicronutrientsFor(%v)[%s] = %v, attendu %v", tt.amount, key, got[key], want)
+				}
+			}
+		})
+	}
+}
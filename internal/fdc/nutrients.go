@@ -0,0 +1,188 @@
+package fdc
+
+import "strings"
+
+// NutrientKey identifie un nutriment indépendamment de la numérotation FDC.
+// Les clés des micronutriments sont celles enregistrées avec chaque repas.
+type NutrientKey string
+
+const (
+	Proteins     NutrientKey = "proteins"
+	Carbs        NutrientKey = "carbs"
+	Fats         NutrientKey = "fats"
+	Calories     NutrientKey = "calories"
+	Fiber        NutrientKey = "fiber"
+	Sodium       NutrientKey = "sodium"
+	Sugars       NutrientKey = "sugars"
+	SaturatedFat NutrientKey = "saturated_fat"
+	Cholesterol  NutrientKey = "cholesterol"
+	Potassium    NutrientKey = "potassium"
+	Calcium      NutrientKey = "calcium"
+	Iron         NutrientKey = "iron"
+	VitaminA     NutrientKey = "vitamin_a"
+	VitaminC     NutrientKey = "vitamin_c"
+	VitaminD     NutrientKey = "vitamin_d"
+	VitaminB12   NutrientKey = "vitamin_b12"
+)
+
+// NutrientSource est un nutriment FDC : son identifiant actuel et son
+// numéro, qui servait d'identifiant dans SR Legacy
+type NutrientSource struct {
+	ID     int
+	Number string
+}
+
+// NutrientDef décrit un nutriment du registre. Sources est classé par ordre
+// de préférence : la première source présente dans les données FDC est
+// retenue (par exemple l'énergie calculée par les facteurs d'Atwater
+// lorsqu'un aliment Foundation n'a pas d'énergie « classique »).
+type NutrientDef struct {
+	Key     NutrientKey
+	Label   string
+	Unit    string
+	Sources []NutrientSource
+}
+
+// Macronutrients est la liste des macronutriments suivis par l'application
+var Macronutrients = []NutrientDef{
+	{Key: Proteins, Label: "Protéines", Unit: "g", Sources: []NutrientSource{{1003, "203"}}},
+	{Key: Carbs, Label: "Glucides", Unit: "g", Sources: []NutrientSource{{1005, "205"}, {1050, "205.2"}}},
+	{Key: Fats, Label: "Lipides", Unit: "g", Sources: []NutrientSource{{1004, "204"}, {1085, "298"}}},
+	{Key: Calories, Label: "Calories", Unit: "kcal", Sources: []NutrientSource{{1008, "208"}, {2047, "957"}, {2048, "958"}}},
+	{Key: Fiber, Label: "Fibres", Unit: "g", Sources: []NutrientSource{{1079, "291"}}},
+}
+
+// NutrientVector associe à chaque nutriment du registre présent dans les
+// données FDC sa valeur, dans l'unité du registre
+type NutrientVector map[NutrientKey]float64
+
+// NutrientVector extrait les nutriments du registre des données FDC de
+// l'aliment, pour 100g, quelle que soit la forme de la réponse (résultat de
+// recherche ou détail d'un aliment Foundation, SR Legacy ou Branded)
+func (f *Food) NutrientVector() NutrientVector {
+	vector := make(NutrientVector)
+	for _, defs := range [][]NutrientDef{Macronutrients, Micronutrients} {
+		for _, def := range defs {
+			if value, ok := f.nutrientValue(def); ok {
+				vector[def.Key] = value
+			}
+		}
+	}
+	return vector
+}
+
+// nutrientValue renvoie la valeur de la source la mieux classée de def
+// parmi les nutriments de l'aliment, convertie dans l'unité du registre
+func (f *Food) nutrientValue(def NutrientDef) (float64, bool) {
+	best, found := len(def.Sources), 0.0
+	for _, n := range f.Nutrients {
+		for rank, source := range def.Sources[:best] {
+			if !n.is(source) {
+				continue
+			}
+			if value, ok := convertUnit(n.value(), n.unit(), def.Unit); ok {
+				best, found = rank, value
+			}
+			break
+		}
+	}
+	return found, best < len(def.Sources)
+}
+
+func (n Nutrient) is(source NutrientSource) bool {
+	if id := n.id(); id != 0 {
+		return id == source.ID
+	}
+	return n.number() != "" && n.number() == source.Number
+}
+
+func (n Nutrient) id() int {
+	if n.ID != 0 {
+		return n.ID
+	}
+	return n.Nutrient.ID
+}
+
+func (n Nutrient) number() string {
+	if n.Number != "" {
+		return n.Number
+	}
+	return n.Nutrient.Number
+}
+
+// value renvoie la valeur du nutriment : « value » dans les résultats de
+// recherche, « amount » dans le détail d'un aliment
+func (n Nutrient) value() float64 {
+	if n.Nutrient.ID != 0 || n.Nutrient.Number != "" {
+		return n.Value
+	}
+	return n.Amount
+}
+
+func (n Nutrient) unit() string {
+	if n.UnitName != "" {
+		return n.UnitName
+	}
+	return n.Nutrient.UnitName
+}
+
+// Facteurs de conversion vers le gramme et la kilocalorie
+var unitFactors = map[string]float64{
+	"g":    1,
+	"mg":   1e-3,
+	"µg":   1e-6,
+	"ug":   1e-6,
+	"mcg":  1e-6,
+	"kcal": 1,
+	"kj":   1 / 4.184,
+}
+
+// convertUnit convertit value de l'unité from vers l'unité to. Une unité
+// absente est considérée comme celle du registre ; des unités incompatibles
+// (UI, masse et énergie...) renvoient false.
+func convertUnit(value float64, from, to string) (float64, bool) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	if from == "" || from == to {
+		return value, true
+	}
+
+	fromFactor, ok := unitFactors[from]
+	toFactor, ok2 := unitFactors[to]
+	if !ok || !ok2 || isEnergyUnit(from) != isEnergyUnit(to) {
+		return 0, false
+	}
+	return value * fromFactor / toFactor, true
+}
+
+func isEnergyUnit(unit string) bool {
+	return unit == "kcal" || unit == "kj"
+}
+
+// NutrientAmount est un nutriment d'un aliment ramené à une forme unique,
+// quelle que soit la forme de la réponse de l'API
+type NutrientAmount struct {
+	ID       int
+	Number   string
+	Name     string
+	UnitName string
+	Amount   float64
+}
+
+// Normalize renvoie le nutriment sous sa forme unique
+func (n Nutrient) Normalize() NutrientAmount {
+	name := n.Name
+	if name == "" {
+		name = n.Nutrient.Name
+	}
+	return NutrientAmount{ID: n.id(), Number: n.number(), Name: name, UnitName: n.unit(), Amount: n.value()}
+}
+
+// Nutrient renvoie le nutriment sous la forme du détail d'un aliment
+func (a NutrientAmount) Nutrient() Nutrient {
+	n := Nutrient{Value: a.Amount, Type: "FoodNutrient"}
+	n.Nutrient.ID = a.ID
+	n.Nutrient.Number = a.Number
+	n.Nutrient.Name = a.Name
+	n.Nutrient.UnitName = a.UnitName
+	return n
+}
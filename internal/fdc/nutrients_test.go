@@ -0,0 +1,167 @@
+package fdc
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNutrientVector(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected NutrientVector
+		absent   []NutrientKey
+	}{
+		{
+			name: "Détail d'un aliment Foundation",
+			body: `{
+				"dataType": "Foundation",
+				"fdcId": 1750340,
+				"description": "Apples, fuji, with skin, raw",
+				"foodNutrients": [
+					{"type": "FoodNutrient", "id": 18557447, "nutrient": {"id": 1003, "number": "203", "name": "Protein", "rank": 600, "unitName": "g"}, "dataPoints": 8, "amount": 0.148},
+					{"type": "FoodNutrient", "nutrient": {"id": 1004, "number": "204", "name": "Total lipid (fat)", "unitName": "g"}, "amount": 0.162},
+					{"type": "FoodNutrient", "nutrient": {"id": 1258, "number": "606", "name": "Fatty acids, total saturated", "unitName": "g"}, "amount": 0.027},
+					{"type": "FoodNutrient", "nutrient": {"id": 1050, "number": "205.2", "name": "Carbohydrate, by summation", "unitName": "g"}, "amount": 15.7},
+					{"type": "FoodNutrient", "nutrient": {"id": 2048, "number": "958", "name": "Energy (Atwater Specific Factors)", "unitName": "kcal"}, "amount": 58.6},
+					{"type": "FoodNutrient", "nutrient": {"id": 2047, "number": "957", "name": "Energy (Atwater General Factors)", "unitName": "kcal"}, "amount": 64.6},
+					{"type": "FoodNutrient", "nutrient": {"id": 1079, "number": "291", "name": "Fiber, total dietary", "unitName": "g"}, "amount": 2.1},
+					{"type": "FoodNutrient", "nutrient": {"id": 1092, "number": "306", "name": "Potassium, K", "unitName": "mg"}, "amount": 109}
+				]
+			}`,
+			expected: NutrientVector{
+				Proteins: 0.148, Fats: 0.162, Carbs: 15.7, Calories: 64.6, Fiber: 2.1,
+				SaturatedFat: 0.027, Potassium: 109,
+			},
+			absent: []NutrientKey{Sodium, Sugars},
+		},
+		{
+			name: "Détail d'un aliment SR Legacy",
+			body: `{
+				"dataType": "SR Legacy",
+				"fdcId": 171688,
+				"description": "Apples, raw, with skin",
+				"foodNutrients": [
+					{"type": "FoodNutrient", "nutrient": {"id": 1062, "number": "268", "name": "Energy", "unitName": "kJ"}, "amount": 218},
+					{"type": "FoodNutrient", "nutrient": {"id": 1008, "number": "208", "name": "Energy", "unitName": "kcal"}, "amount": 52},
+					{"type": "FoodNutrient", "nutrient": {"id": 1003, "number": "203", "name": "Protein", "unitName": "g"}, "amount": 0.26},
+					{"type": "FoodNutrient", "nutrient": {"id": 1004, "number": "204", "name": "Total lipid (fat)", "unitName": "g"}, "amount": 0.17},
+					{"type": "FoodNutrient", "nutrient": {"id": 1005, "number": "205", "name": "Carbohydrate, by difference", "unitName": "g"}, "amount": 13.81},
+					{"type": "FoodNutrient", "nutrient": {"id": 1079, "number": "291", "name": "Fiber, total dietary", "unitName": "g"}, "amount": 2.4},
+					{"type": "FoodNutrient", "nutrient": {"id": 2000, "number": "269", "name": "Sugars, total including NLEA", "unitName": "g"}, "amount": 10.39},
+					{"type": "FoodNutrient", "nutrient": {"id": 1093, "number": "307", "name": "Sodium, Na", "unitName": "mg"}, "amount": 1},
+					{"type": "FoodNutrient", "nutrient": {"id": 1106, "number": "320", "name": "Vitamin A, RAE", "unitName": "µg"}, "amount": 3},
+					{"type": "FoodNutrient", "nutrient": {"id": 1110, "number": "324", "name": "Vitamin D (D2 + D3), International Units", "unitName": "IU"}, "amount": 0}
+				]
+			}`,
+			expected: NutrientVector{
+				Calories: 52, Proteins: 0.26, Fats: 0.17, Carbs: 13.81, Fiber: 2.4,
+				Sugars: 10.39, Sodium: 1, VitaminA: 3,
+			},
+			absent: []NutrientKey{VitaminD, SaturatedFat},
+		},
+		{
+			name: "Résultat de recherche d'un produit Branded",
+			body: `{
+				"dataType": "Branded",
+				"fdcId": 2099213,
+				"description": "Strawberry yogurt",
+				"foodNutrients": [
+					{"nutrientId": 1003, "nutrientName": "Protein", "nutrientNumber": "203", "unitName": "G", "value": 3.33},
+					{"nutrientId": 1004, "nutrientName": "Total lipid (fat)", "nutrientNumber": "204", "unitName": "G", "value": 1.67},
+					{"nutrientId": 1258, "nutrientName": "Fatty acids, total saturated", "nutrientNumber": "606", "unitName": "G", "value": 1},
+					{"nutrientId": 1005, "nutrientName": "Carbohydrate, by difference", "nutrientNumber": "205", "unitName": "G", "value": 13.3},
+					{"nutrientId": 1008, "nutrientName": "Energy", "nutrientNumber": "208", "unitName": "KCAL", "value": 80},
+					{"nutrientId": 2000, "nutrientName": "Total Sugars", "nutrientNumber": "269", "unitName": "G", "value": 11.7},
+					{"nutrientId": 1093, "nutrientName": "Sodium, Na", "nutrientNumber": "307", "unitName": "MG", "value": 50},
+					{"nutrientId": 1087, "nutrientName": "Calcium, Ca", "nutrientNumber": "301", "unitName": "MG", "value": 100}
+				]
+			}`,
+			expected: NutrientVector{
+				Proteins: 3.33, Fats: 1.67, Carbs: 13.3, Calories: 80,
+				SaturatedFat: 1, Sugars: 11.7, Sodium: 50, Calcium: 100,
+			},
+			absent: []NutrientKey{Fiber},
+		},
+		{
+			name: "Détail d'un produit Branded",
+			body: `{
+				"dataType": "Branded",
+				"fdcId": 2099213,
+				"foodNutrients": [
+					{"type": "FoodNutrient", "nutrient": {"id": 1003, "number": "203", "name": "Protein", "unitName": "g"}, "amount": 3.33},
+					{"type": "FoodNutrient", "nutrient": {"id": 1008, "number": "208", "name": "Energy", "unitName": "kcal"}, "amount": 80},
+					{"type": "FoodNutrient", "nutrient": {"id": 1253, "number": "601", "name": "Cholesterol", "unitName": "mg"}, "amount": 8}
+				]
+			}`,
+			expected: NutrientVector{Proteins: 3.33, Calories: 80, Cholesterol: 8},
+			absent:   []NutrientKey{Fats, Carbs},
+		},
+		{
+			name: "Numéros seuls et conversion d'unités",
+			body: `{"foodNutrients": [
+				{"nutrientNumber": "203", "unitName": "G", "value": 12},
+				{"nutrientNumber": "307", "unitName": "G", "value": 0.4},
+				{"nutrientNumber": "418", "unitName": "MCG", "value": 1.5},
+				{"nutrientNumber": "208", "unitName": "kJ", "value": 418.4}
+			]}`,
+			expected: NutrientVector{Proteins: 12, Sodium: 400, VitaminB12: 1.5, Calories: 100},
+		},
+		{
+			name: "Acides gras saturés sans lipides totaux",
+			body: `{"foodNutrients": [
+				{"nutrientId": 1258, "nutrientName": "Fatty acids, total saturated", "unitName": "G", "value": 2}
+			]}`,
+			expected: NutrientVector{SaturatedFat: 2},
+			absent:   []NutrientKey{Fats},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var food Food
+			if err := json.Unmarshal([]byte(tt.body), &food); err != nil {
+				t.Fatalf("Unmarshal() erreur = %v", err)
+			}
+
+			vector := food.NutrientVector()
+			for key, want := range tt.expected {
+				got, ok := vector[key]
+				if !ok {
+					t.Errorf("%s absent, attendu %v", key, want)
+					continue
+				}
+				if math.Abs(got-want) > 0.0001 {
+					t.Errorf("%s = %v, attendu %v", key, got, want)
+				}
+			}
+			for _, key := range tt.absent {
+				if value, ok := vector[key]; ok {
+					t.Errorf("%s = %v, attendu absent", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestNutrientNormalize(t *testing.T) {
+	bodies := []string{
+		`{"nutrientId": 1093, "nutrientName": "Sodium, Na", "nutrientNumber": "307", "unitName": "MG", "value": 50}`,
+		`{"type": "FoodNutrient", "nutrient": {"id": 1093, "number": "307", "name": "Sodium, Na", "unitName": "MG"}, "amount": 50}`,
+	}
+	expected := NutrientAmount{ID: 1093, Number: "307", Name: "Sodium, Na", UnitName: "MG", Amount: 50}
+
+	for _, body := range bodies {
+		var n Nutrient
+		if err := json.Unmarshal([]byte(body), &n); err != nil {
+			t.Fatalf("Unmarshal() erreur = %v", err)
+		}
+		if got := n.Normalize(); got != expected {
+			t.Errorf("Normalize() = %+v, attendu %+v", got, expected)
+		}
+		if got := n.Normalize().Nutrient().Normalize(); got != expected {
+			t.Errorf("Nutrient().Normalize() = %+v, attendu %+v", got, expected)
+		}
+	}
+}
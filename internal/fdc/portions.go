@@ -0,0 +1,234 @@
+package fdc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FoodPortion est une mesure ménagère telle que renvoyée par l'API dans
+// foodPortions (Foundation, SR Legacy, Survey)
+type FoodPortion struct {
+	Amount             float64 `json:"amount"`
+	Value              float64 `json:"value"`
+	GramWeight         float64 `json:"gramWeight"`
+	Modifier           string  `json:"modifier"`
+	PortionDescription string  `json:"portionDescription"`
+	MeasureUnit        struct {
+		Name         string `json:"name"`
+		Abbreviation string `json:"abbreviation"`
+	} `json:"measureUnit"`
+}
+
+// Portion est une unité ménagère utilisable pour saisir une quantité, avec
+// le poids en grammes d'une unité
+type Portion struct {
+	Unit        string  `json:"unit"`
+	Grams       float64 `json:"grams"`
+	Description string  `json:"description"`
+}
+
+// massUnits donne le poids en grammes des unités de masse acceptées pour
+// tous les aliments
+var massUnits = map[string]float64{
+	"g":      1,
+	"gram":   1,
+	"gramme": 1,
+	"kg":     1000,
+	"oz":     28.3495,
+	"ounce":  28.3495,
+	"lb":     453.592,
+	"pound":  453.592,
+}
+
+// brandedServingUnits sont les unités de servingSize prises en compte pour
+// les produits de marque. Les portions en ml sont ignorées : FDC ne donne
+// pas la densité qui permettrait de les convertir en grammes.
+var brandedServingUnits = map[string]bool{"g": true, "grm": true}
+
+// ServingUnit est l'unité correspondant à la portion indiquée sur
+// l'emballage d'un produit de marque
+const ServingUnit = "serving"
+
+// IsMassUnit indique si unit est une unité de masse (g, kg, oz, lb)
+func IsMassUnit(unit string) bool {
+	_, ok := MassUnitGrams(unit)
+	return ok
+}
+
+// MassUnitGrams renvoie le poids en grammes d'une unité de masse ; une unité
+// vide correspond au gramme
+func MassUnitGrams(unit string) (float64, bool) {
+	unit = normalizeUnit(unit)
+	if unit == "" {
+		return 1, true
+	}
+	for name, grams := range massUnits {
+		if unitMatches(name, unit) {
+			return grams, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeUnit(unit string) string {
+	return strings.ToLower(strings.TrimSpace(unit))
+}
+
+// unitMatches compare deux unités en acceptant le pluriel (slice/slices)
+func unitMatches(a, b string) bool {
+	a, b = normalizeUnit(a), normalizeUnit(b)
+	return a == b || a+"s" == b || b+"s" == a || a+"es" == b || b+"es" == a
+}
+
+// ParseQuantity lit une quantité suivie d'une unité facultative, comme
+// "150", "150g", "2 slice", "1.5 cup" ou "1,5 cup". L'unité est vide si elle
+// est absente.
+func ParseQuantity(value string) (float64, string, error) {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && (value[end] >= '0' && value[end] <= '9' || value[end] == '.' || value[end] == ',') {
+		end++
+	}
+
+	quantity, err := parseNumber(value[:end])
+	if err != nil {
+		return 0, "", fmt.Errorf("quantité invalide: %q: %v", value, err)
+	}
+	if quantity <= 0 {
+		return 0, "", fmt.Errorf("quantité invalide: %q", value)
+	}
+	return quantity, normalizeUnit(value[end:]), nil
+}
+
+// parseNumber lit un nombre dont la virgule est soit le séparateur décimal
+// ("1,5"), soit le séparateur des milliers lorsqu'un point la suit
+// ("1,000.5") ou qu'elle se répète ("1,000,000"). Une seule virgule suivie
+// de trois chiffres ("1,000") est ambiguë et refusée.
+func parseNumber(number string) (float64, error) {
+	if strings.Contains(number, ",") {
+		integer, decimals, hasPoint := strings.Cut(number, ".")
+		groups := strings.Split(integer, ",")
+		thousands := hasPoint || len(groups) > 2
+		if thousands {
+			for _, group := range groups[1:] {
+				if len(group) != 3 {
+					return 0, errors.New("séparateur des milliers mal placé")
+				}
+			}
+			number = strings.Join(groups, "")
+			if hasPoint {
+				number += "." + decimals
+			}
+		} else if len(groups[1]) == 3 {
+			return 0, errors.New("virgule ambiguë, utilisez un point pour les décimales")
+		} else {
+			number = groups[0] + "." + groups[1]
+		}
+	}
+	return strconv.ParseFloat(number, 64)
+}
+
+// splitLeadingNumber sépare le nombre initial d'une description comme
+// "2 slices" ; il vaut 1 s'il est absent
+func splitLeadingNumber(description string) (float64, string) {
+	quantity, unit, err := ParseQuantity(description)
+	if err != nil {
+		return 1, normalizeUnit(description)
+	}
+	return quantity, unit
+}
+
+// HouseholdPortions renvoie les unités ménagères connues pour l'aliment,
+// à partir de foodPortions ou, pour un produit de marque, de la portion
+// indiquée sur l'emballage
+func (f *Food) HouseholdPortions() []Portion {
+	var portions []Portion
+	for _, p := range f.Portions {
+		if p.GramWeight <= 0 {
+			continue
+		}
+
+		quantity := p.Amount
+		if quantity <= 0 {
+			quantity = p.Value
+		}
+
+		// Ex: "cup, chopped" ou "slice (1 oz)" : l'unité est le premier mot
+		// du modificateur, s'il en contient un
+		modifierWords := strings.FieldsFunc(p.Modifier, func(r rune) bool {
+			return r == ',' || r == '(' || r == ' '
+		})
+
+		var unit, description string
+		switch {
+		case p.MeasureUnit.Name != "" && p.MeasureUnit.Name != "undetermined":
+			unit = p.MeasureUnit.Name
+			description = strings.TrimSpace(p.MeasureUnit.Name + " " + p.Modifier)
+		case len(modifierWords) > 0:
+			description = p.Modifier
+			unit = modifierWords[0]
+		case p.PortionDescription != "" && !strings.EqualFold(p.PortionDescription, "Quantity not specified"):
+			description = p.PortionDescription
+			quantity, unit = splitLeadingNumber(p.PortionDescription)
+		default:
+			continue
+		}
+		if quantity <= 0 {
+			quantity = 1
+		}
+
+		portions = append(portions, Portion{
+			Unit:        normalizeUnit(unit),
+			Grams:       p.GramWeight / quantity,
+			Description: description,
+		})
+	}
+
+	// Produits de marque : portion de l'emballage, ex: 2 slices (56 g)
+	if f.ServingSize > 0 && brandedServingUnits[normalizeUnit(f.ServingSizeUnit)] {
+		portions = append(portions, Portion{Unit: ServingUnit, Grams: f.ServingSize, Description: f.HouseholdServingFullText})
+
+		if f.HouseholdServingFullText != "" {
+			quantity, unit := splitLeadingNumber(f.HouseholdServingFullText)
+			if unit != "" && !IsMassUnit(unit) {
+				portions = append(portions, Portion{Unit: unit, Grams: f.ServingSize / quantity, Description: f.HouseholdServingFullText})
+			}
+		}
+	}
+	return portions
+}
+
+// GramsFor convertit une quantité exprimée dans une unité (g, kg, oz, lb ou
+// unité ménagère de l'aliment comme "slice" ou "cup") en grammes
+func (f *Food) GramsFor(quantity float64, unit string) (float64, error) {
+	if grams, ok := MassUnitGrams(unit); ok {
+		return quantity * grams, nil
+	}
+
+	portions := f.HouseholdPortions()
+	for _, p := range portions {
+		if p.Unit == normalizeUnit(unit) {
+			return quantity * p.Grams, nil
+		}
+	}
+	for _, p := range portions {
+		if unitMatches(p.Unit, unit) {
+			return quantity * p.Grams, nil
+		}
+	}
+
+	units := make([]string, 0, len(portions))
+	seen := make(map[string]bool)
+	for _, p := range portions {
+		if !seen[p.Unit] {
+			seen[p.Unit] = true
+			units = append(units, p.Unit)
+		}
+	}
+	if len(units) == 0 {
+		return 0, fmt.Errorf("unité %q inconnue pour %s : seules les unités de masse (g, kg, oz, lb) sont disponibles", unit, f.Description)
+	}
+	return 0, fmt.Errorf("unité %q inconnue pour %s (unités disponibles: g, %s)", unit, f.Description, strings.Join(units, ", "))
+}
@@ -0,0 +1,159 @@
+package fdc
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		input    string
+		quantity float64
+		unit     string
+		wantErr  bool
+	}{
+		{input: "150", quantity: 150},
+		{input: "150g", quantity: 150, unit: "g"},
+		{input: "2 slice", quantity: 2, unit: "slice"},
+		{input: "1.5 cup", quantity: 1.5, unit: "cup"},
+		{input: "1,5 Cups", quantity: 1.5, unit: "cups"},
+		{input: "1,000.5g", quantity: 1000.5, unit: "g"},
+		{input: "1,000,000", quantity: 1000000},
+		{input: "1,000g", wantErr: true},
+		{input: "1,00,0.5g", wantErr: true},
+		{input: "1.5.2 cup", wantErr: true},
+		{input: "slice", wantErr: true},
+		{input: "0 cup", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			quantity, unit, err := ParseQuantity(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseQuantity(%q) = %v %q, erreur attendue", tt.input, quantity, unit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuantity(%q) erreur inattendue: %v", tt.input, err)
+			}
+			if quantity != tt.quantity || unit != tt.unit {
+				t.Errorf("ParseQuantity(%q) = %v %q, attendu %v %q", tt.input, quantity, unit, tt.quantity, tt.unit)
+			}
+		})
+	}
+}
+
+func TestGramsFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		quantity float64
+		unit     string
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name:     "SR Legacy : unité dans le modificateur",
+			body:     `{"description": "Bread, whole-wheat", "foodPortions": [{"amount": 1, "gramWeight": 32, "modifier": "slice", "measureUnit": {"name": "undetermined"}}]}`,
+			quantity: 2,
+			unit:     "slice",
+			expected: 64,
+		},
+		{
+			name:     "SR Legacy : modificateur composé et pluriel",
+			body:     `{"description": "Carrots, raw", "foodPortions": [{"amount": 1, "gramWeight": 128, "modifier": "cup, chopped", "measureUnit": {"name": "undetermined"}}]}`,
+			quantity: 1.5,
+			unit:     "cups",
+			expected: 192,
+		},
+		{
+			name:     "Foundation : unité de mesure",
+			body:     `{"description": "Milk, whole", "foodPortions": [{"value": 0.5, "gramWeight": 122, "measureUnit": {"name": "cup", "abbreviation": "cup"}}]}`,
+			quantity: 1,
+			unit:     "cup",
+			expected: 244,
+		},
+		{
+			name:     "Survey : description de la portion",
+			body:     `{"description": "Egg, whole, fried", "foodPortions": [{"gramWeight": 46, "portionDescription": "1 large"}, {"gramWeight": 100, "portionDescription": "Quantity not specified"}]}`,
+			quantity: 3,
+			unit:     "large",
+			expected: 138,
+		},
+		{
+			name:     "Produit de marque : portion de l'emballage",
+			body:     `{"description": "Sandwich bread", "servingSize": 56, "servingSizeUnit": "GRM", "householdServingFullText": "2 slices"}`,
+			quantity: 1,
+			unit:     "serving",
+			expected: 56,
+		},
+		{
+			name:     "Produit de marque : unité ménagère de l'emballage",
+			body:     `{"description": "Sandwich bread", "servingSize": 56, "servingSizeUnit": "g", "householdServingFullText": "2 slices"}`,
+			quantity: 3,
+			unit:     "slice",
+			expected: 84,
+		},
+		{
+			name:     "Produit de marque : portion en ml ignorée",
+			body:     `{"description": "Orange juice", "servingSize": 240, "servingSizeUnit": "MLT", "householdServingFullText": "1 cup"}`,
+			quantity: 1,
+			unit:     "serving",
+			wantErr:  true,
+		},
+		{
+			name:     "Modificateur sans mot : description de la portion",
+			body:     `{"description": "Egg, whole, raw", "foodPortions": [{"gramWeight": 50, "modifier": ", (", "portionDescription": "1 large"}]}`,
+			quantity: 2,
+			unit:     "large",
+			expected: 100,
+		},
+		{
+			name:     "Modificateur sans mot ni description",
+			body:     `{"description": "Bread", "foodPortions": [{"amount": 1, "gramWeight": 32, "modifier": " "}]}`,
+			quantity: 1,
+			unit:     "slice",
+			wantErr:  true,
+		},
+		{
+			name:     "Unité de masse",
+			body:     `{"description": "Rice"}`,
+			quantity: 2,
+			unit:     "oz",
+			expected: 56.699,
+		},
+		{
+			name:     "Unité inconnue",
+			body:     `{"description": "Bread", "foodPortions": [{"amount": 1, "gramWeight": 32, "modifier": "slice"}]}`,
+			quantity: 1,
+			unit:     "cup",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var food Food
+			if err := json.Unmarshal([]byte(tt.body), &food); err != nil {
+				t.Fatalf("Unmarshal() erreur = %v", err)
+			}
+
+			grams, err := food.GramsFor(tt.quantity, tt.unit)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GramsFor(%v, %q) = %v, erreur attendue", tt.quantity, tt.unit, grams)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GramsFor(%v, %q) erreur inattendue: %v", tt.quantity, tt.unit, err)
+			}
+			if math.Abs(grams-tt.expected) > 0.001 {
+				t.Errorf("GramsFor(%v, %q) = %v, attendu %v", tt.quantity, tt.unit, grams, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package fdc
+
+import "context"
+
+// Provider est une source d'aliments FDC : l'API FoodData Central (Client)
+// ou une base locale importée des fichiers téléchargeables de FDC
+type Provider interface {
+	SearchFoodsContext(ctx context.Context, query string) (*SearchResponse, error)
+	SearchFoodsWithOptions(ctx context.Context, query string, opts SearchOptions) (*SearchResponse, error)
+	GetFoodContext(ctx context.Context, fdcID int) (*Food, error)
+	GetFoodsContext(ctx context.Context, fdcIDs []int) ([]Food, error)
+	GetFoodByBarcodeContext(ctx context.Context, code string) (*Food, error)
+}
+
+var _ Provider = (*Client)(nil)
+
+// MergeFoods concatène des listes d'aliments dans l'ordre, sans doublon et
+// dans la limite de limit aliments
+func MergeFoods(limit int, lists ...[]Food) []Food {
+	var merged []Food
+	seen := make(map[int]bool)
+	for _, foods := range lists {
+		for _, food := range foods {
+			if len(merged) >= limit {
+				return merged
+			}
+			if seen[food.FdcID] {
+				continue
+			}
+			seen[food.FdcID] = true
+			merged = append(merged, food)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,35 @@
+package fdc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeFoods(t *testing.T) {
+	local := []Food{{FdcID: 1}, {FdcID: 2}}
+	remote := []Food{{FdcID: 2}, {FdcID: 3}, {FdcID: 4}}
+
+	tests := []struct {
+		name     string
+		limit    int
+		lists    [][]Food
+		expected []int
+	}{
+		{name: "Doublons ignorés", limit: 10, lists: [][]Food{local, remote}, expected: []int{1, 2, 3, 4}},
+		{name: "Limite atteinte", limit: 3, lists: [][]Food{local, remote}, expected: []int{1, 2, 3}},
+		{name: "Liste locale vide", limit: 10, lists: [][]Food{nil, remote}, expected: []int{2, 3, 4}},
+		{name: "Aucun aliment", limit: 10, lists: nil, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []int
+			for _, food := range MergeFoods(tt.limit, tt.lists...) {
+				ids = append(ids, food.FdcID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("MergeFoods = %v, attendu %v", ids, tt.expected)
+			}
+		})
+	}
+}
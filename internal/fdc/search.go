@@ -0,0 +1,143 @@
+package fdc
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Critères de tri des résultats de recherche acceptés par l'API
+const (
+	SortByDescription   = "lowercaseDescription.keyword"
+	SortByDataType      = "dataType.keyword"
+	SortByFdcID         = "fdcId"
+	SortByPublishedDate = "publishedDate"
+)
+
+const (
+	// DefaultPageSize est la taille de page appliquée par l'API
+	DefaultPageSize = 50
+	// MaxPageSize est la plus grande taille de page acceptée par l'API
+	MaxPageSize = 200
+)
+
+// DefaultDataTypes sont les types de données recherchés lorsqu'aucun n'est
+// précisé : les aliments génériques, dont les valeurs sont les plus fiables
+var DefaultDataTypes = []string{"Foundation", "SR Legacy"}
+
+var dataTypes = map[string]bool{
+	"Foundation":     true,
+	"SR Legacy":      true,
+	"Branded":        true,
+	"Survey (FNDDS)": true,
+	"Experimental":   true,
+}
+
+// SearchOptions précise une recherche d'aliments. Les champs vides prennent
+// les valeurs par défaut de l'API, sauf DataTypes (DefaultDataTypes).
+type SearchOptions struct {
+	// PageNumber commence à 1
+	PageNumber int
+	PageSize   int
+	DataTypes  []string
+	// BrandOwner ne retient que les produits de marque de ce fabricant
+	BrandOwner string
+	SortBy     string
+	// SortOrder vaut "asc" ou "desc"
+	SortOrder string
+}
+
+// Validate vérifie les options. Les erreurs sont équivalentes à
+// ErrInvalidSearch.
+func (o SearchOptions) Validate() error {
+	if o.PageNumber < 0 {
+		return fmt.Errorf("%w: page %d", ErrInvalidSearch, o.PageNumber)
+	}
+	if o.PageSize < 0 || o.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: taille de page %d (1 à %d)", ErrInvalidSearch, o.PageSize, MaxPageSize)
+	}
+	for _, dataType := range o.DataTypes {
+		if !dataTypes[dataType] {
+			return fmt.Errorf("%w: type de données %q", ErrInvalidSearch, dataType)
+		}
+	}
+	switch o.SortBy {
+	case "", SortByDescription, SortByDataType, SortByFdcID, SortByPublishedDate:
+	default:
+		return fmt.Errorf("%w: tri %q", ErrInvalidSearch, o.SortBy)
+	}
+	switch o.SortOrder {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("%w: ordre de tri %q", ErrInvalidSearch, o.SortOrder)
+	}
+	return nil
+}
+
+// Page renvoie le numéro de page, 1 par défaut
+func (o SearchOptions) Page() int {
+	return max(o.PageNumber, 1)
+}
+
+// Size renvoie la taille de page, DefaultPageSize par défaut
+func (o SearchOptions) Size() int {
+	if o.PageSize == 0 {
+		return DefaultPageSize
+	}
+	return o.PageSize
+}
+
+// Types renvoie les types de données recherchés
+func (o SearchOptions) Types() []string {
+	if len(o.DataTypes) == 0 {
+		return DefaultDataTypes
+	}
+	return o.DataTypes
+}
+
+// params renvoie les paramètres de l'URL de recherche. Seules les options
+// renseignées y figurent, pour que la clé du cache d'une recherche simple ne
+// dépende pas des valeurs par défaut.
+func (o SearchOptions) params(query string) url.Values {
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("dataType", strings.Join(o.Types(), ","))
+	if o.PageNumber > 0 {
+		params.Add("pageNumber", strconv.Itoa(o.PageNumber))
+	}
+	if o.PageSize > 0 {
+		params.Add("pageSize", strconv.Itoa(o.PageSize))
+	}
+	if o.BrandOwner != "" {
+		params.Add("brandOwner", o.BrandOwner)
+	}
+	if o.SortBy != "" {
+		params.Add("sortBy", o.SortBy)
+	}
+	if o.SortOrder != "" {
+		params.Add("sortOrder", o.SortOrder)
+	}
+	return params
+}
+
+// sortAliases associe des noms courts aux critères de tri de l'API
+var sortAliases = map[string]string{
+	"description":   SortByDescription,
+	"dataType":      SortByDataType,
+	"fdcId":         SortByFdcID,
+	"publishedDate": SortByPublishedDate,
+}
+
+// ParseSort accepte un critère de tri de l'API ou son nom court
+// (description, dataType, fdcId, publishedDate)
+func ParseSort(name string) (string, error) {
+	if sortBy, ok := sortAliases[name]; ok {
+		return sortBy, nil
+	}
+	opts := SearchOptions{SortBy: name}
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
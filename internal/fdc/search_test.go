@@ -0,0 +1,138 @@
+package fdc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSearchOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SearchOptions
+		wantErr bool
+	}{
+		{name: "Options par défaut", opts: SearchOptions{}},
+		{name: "Options complètes", opts: SearchOptions{
+			PageNumber: 3, PageSize: 200, DataTypes: []string{"Branded"},
+			BrandOwner: "Danone", SortBy: SortByFdcID, SortOrder: "desc",
+		}},
+		{name: "Page négative", opts: SearchOptions{PageNumber: -1}, wantErr: true},
+		{name: "Page trop grande", opts: SearchOptions{PageSize: 201}, wantErr: true},
+		{name: "Type inconnu", opts: SearchOptions{DataTypes: []string{"Foundation", "Bio"}}, wantErr: true},
+		{name: "Tri inconnu", opts: SearchOptions{SortBy: "calories"}, wantErr: true},
+		{name: "Ordre inconnu", opts: SearchOptions{SortOrder: "up"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, erreur attendue: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSearch) {
+				t.Errorf("Validate() = %v, attendu ErrInvalidSearch", err)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "description", expected: SortByDescription},
+		{name: "dataType", expected: SortByDataType},
+		{name: "fdcId", expected: SortByFdcID},
+		{name: "lowercaseDescription.keyword", expected: SortByDescription},
+		{name: "calories", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort(%q) = %v, erreur attendue: %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseSort(%q) = %q, attendu %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchFoodsWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     SearchOptions
+		expected url.Values
+	}{
+		{
+			name: "Recherche simple",
+			expected: url.Values{
+				"query":    {"yogurt"},
+				"dataType": {"Foundation,SR Legacy"},
+			},
+		},
+		{
+			name: "Pagination, filtres et tri",
+			opts: SearchOptions{
+				PageNumber: 2, PageSize: 20, DataTypes: []string{"Branded", "Survey (FNDDS)"},
+				BrandOwner: "Danone", SortBy: SortByDescription, SortOrder: "asc",
+			},
+			expected: url.Values{
+				"query":      {"yogurt"},
+				"dataType":   {"Branded,Survey (FNDDS)"},
+				"pageNumber": {"2"},
+				"pageSize":   {"20"},
+				"brandOwner": {"Danone"},
+				"sortBy":     {"lowercaseDescription.keyword"},
+				"sortOrder":  {"asc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				params := r.URL.Query()
+				if params.Encode() != tt.expected.Encode() {
+					t.Errorf("paramètres = %s, attendu %s", params.Encode(), tt.expected.Encode())
+				}
+				w.Write([]byte(`{"foods": [{"fdcId": 1}], "totalHits": 45, "currentPage": 2, "totalPages": 3}`))
+			}))
+			defer server.Close()
+
+			client := NewClientWithBaseURL("test-key", server.URL)
+			result, err := client.SearchFoodsWithOptions(context.Background(), "yogurt", tt.opts)
+			if err != nil {
+				t.Fatalf("SearchFoodsWithOptions() erreur: %v", err)
+			}
+			if result.TotalHits != 45 || result.CurrentPage != 2 || result.TotalPages != 3 {
+				t.Errorf("pagination = %+v", result)
+			}
+		})
+	}
+
+	t.Run("Options invalides", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		client := NewClientWithBaseURL("test-key", server.URL)
+		_, err := client.SearchFoodsWithOptions(context.Background(), "yogurt", SearchOptions{PageSize: 500})
+		if !errors.Is(err, ErrInvalidSearch) {
+			t.Errorf("erreur = %v, attendu ErrInvalidSearch", err)
+		}
+		if requests != 0 {
+			t.Errorf("%d requêtes envoyées, attendu 0", requests)
+		}
+	})
+}
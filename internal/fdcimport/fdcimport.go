@@ -0,0 +1,299 @@
+// Package fdcimport lit les fichiers téléchargeables de FoodData Central
+// (Foundation Foods et SR Legacy, au format JSON ou CSV) pour les importer
+// dans la base locale.
+package fdcimport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// Types de données des fichiers CSV importés, avec leur nom dans l'API
+var csvDataTypes = map[string]string{
+	"foundation_food": "Foundation",
+	"sr_legacy_food":  "SR Legacy",
+}
+
+// Read lit un fichier JSON ou un dossier de fichiers CSV téléchargé depuis
+// FoodData Central
+func Read(path string) ([]fdc.Food, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadCSV(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadJSON(file)
+}
+
+// ReadJSON lit un fichier JSON de FDC, dont les aliments sont rangés dans un
+// tableau (« FoundationFoods », « SRLegacyFoods »...) au premier niveau
+func ReadJSON(r io.Reader) ([]fdc.Food, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	var foods []fdc.Food
+	for decoder.More() {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("JSON invalide: %v", err)
+		}
+
+		// Les aliments sont lus un par un pour ne pas charger deux fois
+		// le fichier en mémoire
+		if err := expectDelim(decoder, '['); err != nil {
+			return nil, err
+		}
+		for decoder.More() {
+			var food fdc.Food
+			if err := decoder.Decode(&food); err != nil {
+				return nil, fmt.Errorf("aliment invalide: %v", err)
+			}
+			foods = append(foods, food)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(foods) == 0 {
+		return nil, errors.New("aucun aliment dans le fichier")
+	}
+	return foods, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("JSON invalide: %v", err)
+	}
+	if token != delim {
+		return fmt.Errorf("JSON invalide: %v attendu, %v trouvé", delim, token)
+	}
+	return nil
+}
+
+// ReadCSV lit le dossier décompressé d'un téléchargement CSV de FDC. Seuls
+// les aliments Foundation et SR Legacy sont conservés.
+func ReadCSV(dir string) ([]fdc.Food, error) {
+	categories := map[string]string{}
+	err := readCSVFile(dir, "food_category.csv", false, func(row csvRow) error {
+		categories[row.get("id")] = row.get("description")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var foods []fdc.Food
+	index := map[int]int{}
+	err = readCSVFile(dir, "food.csv", true, func(row csvRow) error {
+		dataType, ok := csvDataTypes[row.get("data_type")]
+		if !ok {
+			return nil
+		}
+		id, err := row.int("fdc_id")
+		if err != nil {
+			return err
+		}
+
+		food := fdc.Food{FdcID: id, Description: row.get("description"), DataType: dataType}
+		food.FoodCategory.Description = categories[row.get("food_category_id")]
+		index[id] = len(foods)
+		foods = append(foods, food)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nutrients := map[int]fdc.NutrientAmount{}
+	err = readCSVFile(dir, "nutrient.csv", true, func(row csvRow) error {
+		id, err := row.int("id")
+		if err != nil {
+			return err
+		}
+		nutrients[id] = fdc.NutrientAmount{
+			ID:       id,
+			Number:   row.get("nutrient_nbr"),
+			Name:     row.get("name"),
+			UnitName: row.get("unit_name"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readCSVFile(dir, "food_nutrient.csv", true, func(row csvRow) error {
+		food, ok := lookupFood(foods, index, row)
+		if !ok {
+			return nil
+		}
+		nutrientID, err := row.int("nutrient_id")
+		if err != nil {
+			return err
+		}
+		amount, err := row.float("amount")
+		if err != nil {
+			return err
+		}
+
+		nutrient, ok := nutrients[nutrientID]
+		if !ok {
+			nutrient = fdc.NutrientAmount{ID: nutrientID}
+		}
+		nutrient.Amount = amount
+		food.Nutrients = append(food.Nutrients, nutrient.Nutrient())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	units := map[string]string{}
+	err = readCSVFile(dir, "measure_unit.csv", false, func(row csvRow) error {
+		units[row.get("id")] = row.get("name")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readCSVFile(dir, "food_portion.csv", false, func(row csvRow) error {
+		food, ok := lookupFood(foods, index, row)
+		if !ok {
+			return nil
+		}
+		// Une portion sans poids est conservée avec un poids nul ; elle est
+		// ignorée lors de l'enregistrement
+		var gramWeight float64
+		if row.get("gram_weight") != "" {
+			var err error
+			if gramWeight, err = row.float("gram_weight"); err != nil {
+				return err
+			}
+		}
+		amount, _ := row.float("amount")
+
+		portion := fdc.FoodPortion{
+			Amount:             amount,
+			GramWeight:         gramWeight,
+			Modifier:           row.get("modifier"),
+			PortionDescription: row.get("portion_description"),
+		}
+		portion.MeasureUnit.Name = units[row.get("measure_unit_id")]
+		food.Portions = append(food.Portions, portion)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(foods) == 0 {
+		return nil, errors.New("aucun aliment Foundation ou SR Legacy dans food.csv")
+	}
+	return foods, nil
+}
+
+// lookupFood renvoie l'aliment désigné par la colonne fdc_id de la ligne,
+// s'il fait partie des aliments importés
+func lookupFood(foods []fdc.Food, index map[int]int, row csvRow) (*fdc.Food, bool) {
+	id, err := row.int("fdc_id")
+	if err != nil {
+		return nil, false
+	}
+	i, ok := index[id]
+	if !ok {
+		return nil, false
+	}
+	return &foods[i], true
+}
+
+// csvRow est une ligne d'un fichier CSV de FDC, dont les colonnes sont
+// désignées par leur nom
+type csvRow struct {
+	file    string
+	line    int
+	columns map[string]int
+	record  []string
+}
+
+func (r csvRow) get(column string) string {
+	if i, ok := r.columns[column]; ok && i < len(r.record) {
+		return r.record[i]
+	}
+	return ""
+}
+
+func (r csvRow) int(column string) (int, error) {
+	value, err := strconv.Atoi(r.get(column))
+	if err != nil {
+		return 0, fmt.Errorf("%s, ligne %d: %s invalide: %q", r.file, r.line, column, r.get(column))
+	}
+	return value, nil
+}
+
+func (r csvRow) float(column string) (float64, error) {
+	value, err := strconv.ParseFloat(r.get(column), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s, ligne %d: %s invalide: %q", r.file, r.line, column, r.get(column))
+	}
+	return value, nil
+}
+
+// readCSVFile appelle fn pour chaque ligne du fichier name du dossier dir.
+// Un fichier facultatif absent est ignoré.
+func readCSVFile(dir, name string, required bool, fn func(row csvRow) error) error {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: en-tête illisible: %v", name, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimPrefix(column, "\ufeff")] = i
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s, ligne %d: %v", name, line, err)
+		}
+		if err := fn(csvRow{file: name, line: line, columns: columns, record: record}); err != nil {
+			return err
+		}
+	}
+}
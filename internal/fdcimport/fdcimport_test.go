@@ -0,0 +1,145 @@
+package fdcimport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+func TestReadJSON(t *testing.T) {
+	input := `{"SRLegacyFoods": [
+		{
+			"fdcId": 171688,
+			"description": "Apples, raw, with skin (Includes foods for USDA's Food Distribution Program)",
+			"dataType": "SR Legacy",
+			"foodCategory": {"description": "Fruits and Fruit Juices"},
+			"foodNutrients": [
+				{"type": "FoodNutrient", "id": 1, "nutrient": {"id": 1003, "number": "203", "name": "Protein", "rank": 600, "unitName": "g"}, "amount": 0.26},
+				{"type": "FoodNutrient", "id": 2, "nutrient": {"id": 1008, "number": "208", "name": "Energy", "rank": 300, "unitName": "kcal"}, "amount": 52}
+			],
+			"foodPortions": [
+				{"id": 1, "value": 1, "measureUnit": {"id": 9999, "name": "undetermined", "abbreviation": "undetermined"}, "modifier": "cup, sliced", "gramWeight": 109, "sequenceNumber": 1, "amount": 1}
+			]
+		},
+		{"fdcId": 171705, "description": "Bananas, raw", "dataType": "SR Legacy", "foodNutrients": []}
+	]}`
+
+	foods, err := ReadJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadJSON() erreur = %v", err)
+	}
+	if len(foods) != 2 {
+		t.Fatalf("%d aliments lus, attendu 2", len(foods))
+	}
+
+	apple := foods[0]
+	if apple.FdcID != 171688 || apple.Category() != "Fruits and Fruit Juices" {
+		t.Errorf("aliment = %d (%s)", apple.FdcID, apple.Category())
+	}
+	if proteins, _, _, calories, _ := apple.GetMacros(); proteins != 0.26 || calories != 52 {
+		t.Errorf("macros = %v protéines, %v calories", proteins, calories)
+	}
+	if grams, err := apple.GramsFor(1, "cup"); err != nil || grams != 109 {
+		t.Errorf("GramsFor(1, cup) = %v, %v, attendu 109", grams, err)
+	}
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	tests := map[string]string{
+		"Fichier vide":        ``,
+		"Sans tableau":        `{"FoundationFoods": {}}`,
+		"Aucun aliment":       `{"FoundationFoods": []}`,
+		"Aliment mal formé":   `{"FoundationFoods": [{"fdcId": "abc"}]}`,
+		"Premier niveau faux": `[]`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadJSON(strings.NewReader(input)); err == nil {
+				t.Error("ReadJSON() sans erreur, erreur attendue")
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"food.csv": `"fdc_id","data_type","description","food_category_id","publication_date"
+"321358","sample_food","Hummus, commercial","16","2019-04-01"
+"321360","foundation_food","Hummus, commercial","16","2019-04-01"
+"171688","sr_legacy_food","Apples, raw, with skin","9","2019-04-01"
+`,
+		"food_category.csv": `"id","code","description"
+"9","0900","Fruits and Fruit Juices"
+"16","1600","Legumes and Legume Products"
+`,
+		"nutrient.csv": `"id","name","unit_name","nutrient_nbr","rank"
+"1003","Protein","G","203","600.0"
+"1008","Energy","KCAL","208","300.0"
+"1093","Sodium, Na","MG","307","5800.0"
+`,
+		"food_nutrient.csv": `"id","fdc_id","nutrient_id","amount","data_points","derivation_id","min","max","median","footnote","min_year_acquired"
+"1","321358","1003","7.9","","","","","","",""
+"2","321360","1003","7.35","12","1","","","","",""
+"3","321360","1093","426","","","","","","",""
+"4","171688","1008","52","","","","","","",""
+`,
+		"measure_unit.csv": `"id","name"
+"1000","cup"
+"9999","undetermined"
+`,
+		"food_portion.csv": `"id","fdc_id","seq_num","amount","measure_unit_id","portion_description","modifier","gram_weight","data_points","footnote","min_year_acquired"
+"1","321360","1","1","1001","","","","","",""
+"2","321360","2","2","1000","","","30.0","","",""
+"3","171688","1","1","9999","","cup, sliced","109","","",""
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	foods, err := ReadCSV(dir)
+	if err != nil {
+		t.Fatalf("ReadCSV() erreur = %v", err)
+	}
+	if len(foods) != 2 {
+		t.Fatalf("%d aliments lus, attendu 2 (les échantillons sont ignorés)", len(foods))
+	}
+
+	hummus := foods[0]
+	if hummus.FdcID != 321360 || hummus.DataType != "Foundation" || hummus.Category() != "Legumes and Legume Products" {
+		t.Errorf("aliment = %d %s (%s)", hummus.FdcID, hummus.DataType, hummus.Category())
+	}
+	vector := hummus.NutrientVector()
+	if vector[fdc.Proteins] != 7.35 || vector[fdc.Sodium] != 426 {
+		t.Errorf("nutriments = %v", vector)
+	}
+	if grams, err := hummus.GramsFor(2, "cup"); err != nil || grams != 30 {
+		t.Errorf("GramsFor(2, cup) = %v, %v, attendu 30", grams, err)
+	}
+	// La portion sans poids est lue avec un poids nul, ignoré à l'import
+	if len(hummus.Portions) != 2 || hummus.Portions[0].GramWeight != 0 {
+		t.Errorf("portions = %+v, attendu 2 portions dont une sans poids", hummus.Portions)
+	}
+
+	apple := foods[1]
+	if apple.DataType != "SR Legacy" || apple.NutrientVector()[fdc.Calories] != 52 {
+		t.Errorf("pomme = %s, %v", apple.DataType, apple.NutrientVector())
+	}
+	if grams, err := apple.GramsFor(1, "cup"); err != nil || grams != 109 {
+		t.Errorf("GramsFor(1, cup) = %v, %v, attendu 109", grams, err)
+	}
+
+	// Un poids illisible reste une erreur de fichier
+	files["food_portion.csv"] = strings.Replace(files["food_portion.csv"], `"1","321360","1","1","1001","","","",`, `"1","321360","1","1","1001","","","abc",`, 1)
+	os.WriteFile(filepath.Join(dir, "food_portion.csv"), []byte(files["food_portion.csv"]), 0o644)
+	if _, err := ReadCSV(dir); err == nil || !strings.Contains(err.Error(), "food_portion.csv, ligne 2") {
+		t.Errorf("ReadCSV() erreur = %v, attendu une erreur à la ligne 2 de food_portion.csv", err)
+	}
+}
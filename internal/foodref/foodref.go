@@ -0,0 +1,50 @@
+// Package foodref identifie un aliment quelle que soit sa source : aliment
+// FoodData Central, aliment personnalisé ou recette. Une référence s'écrit
+// "<source>:<id>", par exemple "perso:12" ; un identifiant seul désigne un
+// aliment FDC.
+package foodref
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Source string
+
+const (
+	FDC    Source = "fdc"
+	Custom Source = "perso"
+	Recipe Source = "recette"
+)
+
+type Ref struct {
+	Source Source
+	ID     int
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%s:%d", r.Source, r.ID)
+}
+
+// Parse lit une référence d'aliment
+func Parse(value string) (Ref, error) {
+	source, idStr, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		source, idStr = string(FDC), source
+	}
+
+	ref := Ref{Source: Source(strings.ToLower(source))}
+	switch ref.Source {
+	case FDC, Custom, Recipe:
+	default:
+		return Ref{}, fmt.Errorf("source d'aliment inconnue: %q (attendu: fdc, perso ou recette)", source)
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return Ref{}, fmt.Errorf("identifiant d'aliment invalide: %q", idStr)
+	}
+	ref.ID = id
+	return ref, nil
+}
@@ -0,0 +1,41 @@
+package foodref
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Ref
+		wantErr  bool
+	}{
+		{input: "173944", expected: Ref{Source: FDC, ID: 173944}},
+		{input: "fdc:173944", expected: Ref{Source: FDC, ID: 173944}},
+		{input: "perso:12", expected: Ref{Source: Custom, ID: 12}},
+		{input: "Recette:3", expected: Ref{Source: Recipe, ID: 3}},
+		{input: "autre:3", wantErr: true},
+		{input: "perso:", wantErr: true},
+		{input: "perso:-1", wantErr: true},
+		{input: "pomme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := Parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %v, erreur attendue", tt.input, ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) erreur inattendue: %v", tt.input, err)
+			}
+			if ref != tt.expected {
+				t.Errorf("Parse(%q) = %v, attendu %v", tt.input, ref, tt.expected)
+			}
+			if again, err := Parse(ref.String()); err != nil || again != ref {
+				t.Errorf("Parse(%q) = %v, %v, attendu %v", ref.String(), again, err, ref)
+			}
+		})
+	}
+}
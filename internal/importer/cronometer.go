@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// cronometerTimeLayouts sont les formats d'heure rencontrés dans la colonne Time
+var cronometerTimeLayouts = []string{"3:04 PM", "15:04", "15:04:05"}
+
+// cronometerMicronutrients associe les colonnes de Cronometer aux
+// micronutriments suivis. La vitamine D, exprimée en UI, n'est pas reprise.
+var cronometerMicronutrients = map[string]fdc.NutrientKey{
+	"Sodium (mg)":          fdc.Sodium,
+	"Sugars (g)":           fdc.Sugars,
+	"Saturated (g)":        fdc.SaturatedFat,
+	"Cholesterol (mg)":     fdc.Cholesterol,
+	"Potassium (mg)":       fdc.Potassium,
+	"Calcium (mg)":         fdc.Calcium,
+	"Iron (mg)":            fdc.Iron,
+	"Vitamin A (µg)":       fdc.VitaminA,
+	"Vitamin C (mg)":       fdc.VitaminC,
+	"B12 (Cobalamin) (µg)": fdc.VitaminB12,
+}
+
+// ReadCronometer lit l'export « Servings » de Cronometer, qui contient
+// une ligne par aliment consommé
+func ReadCronometer(r io.Reader, userID int) (*Result, error) {
+	required := []string{"Day", "Group", "Food Name", "Amount", "Energy (kcal)", "Carbs (g)", "Fat (g)", "Protein (g)"}
+
+	return readRecords(r, required, func(cols columns, record []string) (database.Meal, error) {
+		meal := database.Meal{UserID: userID}
+
+		date, err := time.ParseInLocation("2006-01-02", cols.get(record, "Day"), time.Local)
+		if err != nil {
+			return meal, fmt.Errorf("date invalide: %q", cols.get(record, "Day"))
+		}
+		if clock := cols.get(record, "Time"); clock != "" {
+			for _, layout := range cronometerTimeLayouts {
+				if t, err := time.Parse(layout, clock); err == nil {
+					date = date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+					break
+				}
+			}
+		}
+		meal.MealDate = date
+
+		group := cols.get(record, "Group")
+		mealType, ok := database.MapMealType(group)
+		if !ok {
+			return meal, fmt.Errorf("repas inconnu: %q", group)
+		}
+		meal.MealType = string(mealType)
+
+		meal.FoodName = cols.get(record, "Food Name")
+		if meal.FoodName == "" {
+			return meal, fmt.Errorf("nom d'aliment manquant")
+		}
+
+		if meal.Amount, err = gramsFromAmount(cols.get(record, "Amount")); err != nil {
+			return meal, err
+		}
+
+		values := map[string]*float64{
+			"Energy (kcal)": &meal.Calories,
+			"Fat (g)":       &meal.Fats,
+			"Carbs (g)":     &meal.Carbs,
+			"Protein (g)":   &meal.Proteins,
+			"Fiber (g)":     &meal.Fiber,
+		}
+		for name, value := range values {
+			if *value, err = cols.float(record, name); err != nil {
+				return meal, err
+			}
+		}
+		if meal.Micronutrients, err = cols.micronutrients(record, cronometerMicronutrients); err != nil {
+			return meal, err
+		}
+
+		return meal, nil
+	})
+}
+
+// gramsFromAmount convertit en grammes une quantité comme « 150.00 g » ou
+// « 2.00 oz ». Les unités ménagères (cup, serving...), dont l'export
+// n'indique pas le poids, sont refusées.
+func gramsFromAmount(amount string) (float64, error) {
+	fields := strings.Fields(strings.ReplaceAll(amount, ",", ""))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("quantité invalide: %q", amount)
+	}
+	quantity, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || quantity <= 0 {
+		return 0, fmt.Errorf("quantité invalide: %q", amount)
+	}
+	unit := strings.Join(fields[1:], " ")
+	grams, ok := fdc.MassUnitGrams(unit)
+	if !ok {
+		return 0, fmt.Errorf("unité %q non convertible en grammes: %q", unit, amount)
+	}
+	return quantity * grams, nil
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/export"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// ReadCSV lit un fichier produit par l'export CSV de Macro-Tracker, avec ou
+// sans les colonnes de micronutriments. Les lignes invalides, y compris
+// celles dont un guillemet est mal placé, sont signalées avec leur numéro de
+// ligne ; une erreur n'est renvoyée que si le fichier lui-même est illisible.
+func ReadCSV(r io.Reader, userID int) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("fichier vide")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("en-tête illisible: %v", err)
+	}
+	header = normalizeHeader(header)
+	if !validCSVHeader(header) {
+		return nil, fmt.Errorf("en-tête inattendu: %s (attendu: %s)",
+			strings.Join(header, ","), strings.Join(export.CSVHeader, ","))
+	}
+
+	result := &Result{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if result.addSyntaxError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fichier CSV illisible: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			result.addError(line, "%d colonnes au lieu de %d", len(record), len(header))
+			continue
+		}
+
+		meal, err := parseCSVRecord(record)
+		if err != nil {
+			result.addError(line, "%v", err)
+			continue
+		}
+		meal.UserID = userID
+		result.Meals = append(result.Meals, meal)
+	}
+
+	return result, nil
+}
+
+// validCSVHeader accepte l'en-tête complet de l'export et celui des exports
+// antérieurs, sans micronutriments
+func validCSVHeader(header []string) bool {
+	if len(header) != len(export.CSVHeader) && len(header) != export.CSVMacroColumns {
+		return false
+	}
+	for i, name := range header {
+		if name != export.CSVHeader[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCSVRecord(record []string) (database.Meal, error) {
+	var meal database.Meal
+
+	date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(record[0]), time.Local)
+	if err != nil {
+		return meal, fmt.Errorf("date invalide: %q", record[0])
+	}
+	meal.MealDate = date
+
+	meal.MealType = strings.TrimSpace(record[1])
+	if meal.MealType == "" {
+		return meal, fmt.Errorf("type de repas manquant")
+	}
+
+	meal.FoodName = strings.TrimSpace(record[2])
+	if meal.FoodName == "" {
+		return meal, fmt.Errorf("nom d'aliment manquant")
+	}
+
+	values := []*float64{&meal.Amount, &meal.Calories, &meal.Proteins, &meal.Carbs, &meal.Fats, &meal.Fiber}
+	for i, value := range values {
+		column := export.CSVHeader[i+3]
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[i+3]), 64)
+		if err != nil {
+			return meal, fmt.Errorf("%s invalide: %q", column, record[i+3])
+		}
+		if v < 0 {
+			return meal, fmt.Errorf("%s négatif: %q", column, record[i+3])
+		}
+		*value = v
+	}
+
+	if meal.Amount == 0 {
+		return meal, fmt.Errorf("quantité nulle")
+	}
+
+	// Une cellule vide correspond à un micronutriment inconnu
+	for i, cell := range record[export.CSVMacroColumns:] {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			continue
+		}
+		column := export.CSVHeader[export.CSVMacroColumns+i]
+		v, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return meal, fmt.Errorf("%s invalide: %q", column, cell)
+		}
+		if v < 0 {
+			return meal, fmt.Errorf("%s négatif: %q", column, cell)
+		}
+		if meal.Micronutrients == nil {
+			meal.Micronutrients = make(database.Micronutrients)
+		}
+		meal.Micronutrients[string(fdc.Micronutrients[i].Key)] = v
+	}
+
+	return meal, nil
+}
@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/export"
+)
+
+func TestReadCSVRoundTrip(t *testing.T) {
+	meals := []database.Meal{
+		{
+			MealType: "dejeuner", MealDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local),
+			FoodName: "Chicken, breast", Amount: 150, Calories: 247.5, Proteins: 46.5, Carbs: 0, Fats: 5.4, Fiber: 0,
+			Micronutrients: database.Micronutrients{"sodium": 111, "vitamin_b12": 0.45},
+		},
+		{
+			MealType: "breakfast", MealDate: time.Date(2024, 3, 16, 0, 0, 0, 0, time.Local),
+			FoodName: "Oats", Amount: 80, Calories: 303.2, Proteins: 10.5, Carbs: 54.2, Fats: 5.3, Fiber: 8.1,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, export.CSV, meals); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+
+	result, err := ReadCSV(&buf, 42)
+	if err != nil {
+		t.Fatalf("ReadCSV() erreur = %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("Erreurs inattendues: %v", result.Errors)
+	}
+	if len(result.Meals) != len(meals) {
+		t.Fatalf("%d repas lus, attendu %d", len(result.Meals), len(meals))
+	}
+
+	for i, meal := range result.Meals {
+		expected := meals[i]
+		expected.UserID = 42
+		if !meal.MealDate.Equal(expected.MealDate) {
+			t.Errorf("Repas %d: date = %v, attendu %v", i, meal.MealDate, expected.MealDate)
+		}
+		meal.MealDate = expected.MealDate
+		if !reflect.DeepEqual(meal, expected) {
+			t.Errorf("Repas %d = %+v, attendu %+v", i, meal, expected)
+		}
+	}
+}
+
+func TestReadCSVRowErrors(t *testing.T) {
+	input := "\ufeffDate,Type de repas,Aliment,Quantité (g),Calories,Protéines,Glucides,Lipides,Fibres\n" +
+		"2024-03-15,diner,Riz,200.0,260.0,5.4,56.0,0.6,0.8\n" +
+		"15/03/2024,diner,Riz,200.0,260.0,5.4,56.0,0.6,0.8\n" +
+		"2024-03-15,,Riz,200.0,260.0,5.4,56.0,0.6,0.8\n" +
+		"2024-03-15,diner,Riz,abc,260.0,5.4,56.0,0.6,0.8\n" +
+		"2024-03-15,diner,Riz,200.0,-1,5.4,56.0,0.6,0.8\n" +
+		"2024-03-15,diner,Riz,200.0\n" +
+		"2024-03-15,diner,Riz \"complet\",200.0,260.0,5.4,56.0,0.6,0.8\n" +
+		"2024-03-16,diner,Riz,200.0,260.0,5.4,56.0,0.6,0.8\n"
+
+	result, err := ReadCSV(strings.NewReader(input), 1)
+	if err != nil {
+		t.Fatalf("ReadCSV() erreur = %v", err)
+	}
+	if len(result.Meals) != 2 {
+		t.Errorf("%d repas valides, attendu 2", len(result.Meals))
+	}
+
+	expectedLines := []int{3, 4, 5, 6, 7, 8}
+	if len(result.Errors) != len(expectedLines) {
+		t.Fatalf("Erreurs = %v, attendu %d erreurs", result.Errors, len(expectedLines))
+	}
+	for i, line := range expectedLines {
+		if result.Errors[i].Line != line {
+			t.Errorf("Erreur %d à la ligne %d, attendu %d", i, result.Errors[i].Line, line)
+		}
+	}
+}
+
+func TestReadCSVInvalidHeader(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("Date,Food,Calories\n"), 1)
+	if err == nil {
+		t.Error("Une erreur était attendue pour un en-tête inconnu")
+	}
+}
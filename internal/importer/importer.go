@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// RowError décrit une ligne rejetée lors d'un import
+type RowError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("ligne %d: %s", e.Line, e.Err)
+}
+
+// Result contient les repas lus et les lignes rejetées d'un import
+type Result struct {
+	Meals  []database.Meal `json:"meals"`
+	Errors []RowError      `json:"errors"`
+}
+
+func (r *Result) addError(line int, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, RowError{Line: line, Err: fmt.Sprintf(format, args...)})
+}
+
+// addSyntaxError signale une erreur de syntaxe CSV (guillemet mal placé ou
+// non fermé) sur la ligne de l'enregistrement concerné. Elle renvoie false
+// pour les autres erreurs, qui empêchent de lire la suite du fichier.
+func (r *Result) addSyntaxError(err error) bool {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return false
+	}
+	r.addError(parseErr.StartLine, "ligne CSV illisible: %v", parseErr.Err)
+	return true
+}
+
+// normalizeHeader supprime le BOM UTF-8 et les espaces autour des noms de colonnes
+func normalizeHeader(header []string) []string {
+	normalized := make([]string, len(header))
+	for i, name := range header {
+		normalized[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	return normalized
+}
+
+// Source désigne le format d'un fichier à importer
+type Source string
+
+const (
+	MacroTracker Source = "csv"
+	MyFitnessPal Source = "mfp"
+	Cronometer   Source = "cronometer"
+)
+
+// ParseSource convertit un nom de source, insensible à la casse
+func ParseSource(name string) (Source, error) {
+	switch strings.ToLower(name) {
+	case "", "csv", "macro-tracker":
+		return MacroTracker, nil
+	case "mfp", "myfitnesspal":
+		return MyFitnessPal, nil
+	case "cronometer":
+		return Cronometer, nil
+	}
+	return "", fmt.Errorf("source d'import inconnue: %s (attendu: csv, mfp ou cronometer)", name)
+}
+
+// Read lit un fichier au format de la source indiquée
+func Read(source Source, r io.Reader, userID int) (*Result, error) {
+	switch source {
+	case MacroTracker:
+		return ReadCSV(r, userID)
+	case MyFitnessPal:
+		return ReadMyFitnessPal(r, userID)
+	case Cronometer:
+		return ReadCronometer(r, userID)
+	}
+	return nil, fmt.Errorf("source d'import inconnue: %s", source)
+}
+
+// columns associe les noms de colonnes, en minuscules, à leur position
+type columns map[string]int
+
+func newColumns(header []string) columns {
+	cols := make(columns)
+	for i, name := range normalizeHeader(header) {
+		cols[strings.ToLower(name)] = i
+	}
+	return cols
+}
+
+// require vérifie la présence des colonnes indispensables
+func (c columns) require(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if _, ok := c[strings.ToLower(name)]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("colonnes manquantes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// get renvoie la valeur d'une colonne, ou une chaîne vide si elle est absente
+func (c columns) get(record []string, name string) string {
+	i, ok := c[strings.ToLower(name)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// float lit une valeur numérique ; une cellule vide vaut 0
+func (c columns) float(record []string, name string) (float64, error) {
+	value := strings.ReplaceAll(c.get(record, name), ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s invalide: %q", name, value)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("%s négatif: %q", name, value)
+	}
+	return v, nil
+}
+
+// micronutrients lit les colonnes de micronutriments présentes dans le
+// fichier, associées dans names à leur clé. Les colonnes absentes et les
+// cellules vides sont ignorées ; le résultat est nil si aucune n'est lue.
+func (c columns) micronutrients(record []string, names map[string]fdc.NutrientKey) (database.Micronutrients, error) {
+	var values database.Micronutrients
+	for name, key := range names {
+		if c.get(record, name) == "" {
+			continue
+		}
+		v, err := c.float(record, name)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = make(database.Micronutrients)
+		}
+		values[string(key)] = v
+	}
+	return values, nil
+}
+
+// readRecords lit un CSV dont la première ligne est l'en-tête et appelle
+// parse pour chaque ligne de données
+func readRecords(r io.Reader, required []string, parse func(cols columns, record []string) (database.Meal, error)) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("fichier vide")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("en-tête illisible: %v", err)
+	}
+	cols := newColumns(header)
+	if err := cols.require(required...); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if result.addSyntaxError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fichier CSV illisible: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		meal, err := parse(cols, record)
+		if err != nil {
+			result.addError(line, "%v", err)
+			continue
+		}
+		result.Meals = append(result.Meals, meal)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+func TestReadMyFitnessPal(t *testing.T) {
+	input := "Date,Meal,Calories,Fat (g),Saturated Fat,Polyunsaturated Fat,Monounsaturated Fat,Trans Fat,Cholesterol,Sodium (mg),Potassium,Carbohydrates (g),Fiber,Sugar,Protein (g),Vitamin A,Vitamin C,Calcium,Iron,Note\n" +
+		"2024-03-15,Breakfast,450.0,12.5,3.0,1.0,5.0,0.0,210.0,380.0,300.0,55.0,6.0,12.0,25.0,10.0,5.0,15.0,20.0,\n" +
+		"2024-03-15,Meal 6,100.0,1.0,0,0,0,0,0,0,0,10.0,0,0,5.0,0,0,0,0,\n" +
+		"2024-03-15,Dinner,\"1,250.0\",40.0,10.0,5.0,15.0,0.0,90.0,900.0,800.0,120.0,9.0,8.0,80.0,20.0,30.0,10.0,25.0,\n"
+
+	result, err := Read(MyFitnessPal, strings.NewReader(input), 7)
+	if err != nil {
+		t.Fatalf("Read() erreur = %v", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Erreurs = %v, attendu une erreur à la ligne 3", result.Errors)
+	}
+	if len(result.Meals) != 2 {
+		t.Fatalf("%d repas lus, attendu 2", len(result.Meals))
+	}
+
+	breakfast := result.Meals[0]
+	if breakfast.UserID != 7 || breakfast.MealType != string(database.Breakfast) || breakfast.FoodName != "MyFitnessPal - Breakfast" {
+		t.Errorf("Petit-déjeuner = %+v", breakfast)
+	}
+	if breakfast.Calories != 450 || breakfast.Fats != 12.5 || breakfast.Carbs != 55 || breakfast.Proteins != 25 || breakfast.Fiber != 6 {
+		t.Errorf("Nutriments du petit-déjeuner = %+v", breakfast)
+	}
+	if breakfast.Micronutrients["sodium"] != 380 || breakfast.Micronutrients["potassium"] != 300 || breakfast.Micronutrients["sugars"] != 12 {
+		t.Errorf("Micronutriments du petit-déjeuner = %v", breakfast.Micronutrients)
+	}
+	if _, ok := breakfast.Micronutrients["iron"]; ok {
+		t.Errorf("Le fer, en pourcentage des apports, ne doit pas être importé: %v", breakfast.Micronutrients)
+	}
+	if result.Meals[1].Calories != 1250 {
+		t.Errorf("Calories du dîner = %v, attendu 1250", result.Meals[1].Calories)
+	}
+}
+
+func TestReadCronometer(t *testing.T) {
+	input := "Day,Time,Group,Food Name,Amount,Energy (kcal),Alcohol (g),Caffeine (mg),Water (g),Carbs (g),Fiber (g),Sugars (g),Fat (g),Protein (g),Category\n" +
+		"2024-03-15,8:15 AM,Breakfast,\"Oats, Rolled\",80.00 g,303.2,0,0,8,54.2,8.1,0.8,5.3,10.5,Cereals\n" +
+		"2024-03-15,,Uncategorized,Whole Milk,8.00 oz,149.0,0,0,215,11.7,0,12.3,7.9,7.7,Dairy\n" +
+		"2024-03-15,12:00 PM,Lunch,,100.00 g,100,0,0,0,0,0,0,0,0,\n" +
+		"2024-03-15,12:00 PM,Lunch,Rice,150.00 g,abc,0,0,0,0,0,0,0,0,Grains\n" +
+		"2024-03-15,7:00 PM,Dinner,Soup,1.00 cup,80,0,0,200,10,1,2,3,4,Soups\n"
+
+	result, err := Read(Cronometer, strings.NewReader(input), 3)
+	if err != nil {
+		t.Fatalf("Read() erreur = %v", err)
+	}
+	if len(result.Errors) != 3 || result.Errors[0].Line != 4 || result.Errors[1].Line != 5 || result.Errors[2].Line != 6 {
+		t.Fatalf("Erreurs = %v, attendu des erreurs aux lignes 4, 5 et 6", result.Errors)
+	}
+	if !strings.Contains(result.Errors[2].Err, "cup") {
+		t.Errorf("Erreur = %q, attendu une erreur citant l'unité cup", result.Errors[2].Err)
+	}
+	if len(result.Meals) != 2 {
+		t.Fatalf("%d repas lus, attendu 2", len(result.Meals))
+	}
+
+	oats := result.Meals[0]
+	if oats.FoodName != "Oats, Rolled" || oats.Amount != 80 || oats.MealType != string(database.Breakfast) {
+		t.Errorf("Flocons d'avoine = %+v", oats)
+	}
+	if oats.MealDate.Hour() != 8 || oats.MealDate.Minute() != 15 {
+		t.Errorf("Heure = %v, attendu 08:15", oats.MealDate)
+	}
+	if oats.Calories != 303.2 || oats.Carbs != 54.2 || oats.Fiber != 8.1 || oats.Fats != 5.3 || oats.Proteins != 10.5 {
+		t.Errorf("Nutriments des flocons d'avoine = %+v", oats)
+	}
+
+	if oats.Micronutrients["sugars"] != 0.8 || len(oats.Micronutrients) != 1 {
+		t.Errorf("Micronutriments des flocons d'avoine = %v, attendu uniquement les sucres", oats.Micronutrients)
+	}
+
+	milk := result.Meals[1]
+	if math.Abs(milk.Amount-226.796) > 0.001 || milk.MealType != string(database.Snack2) {
+		t.Errorf("Lait = %+v, attendu 226.796 g et le type snack2", milk)
+	}
+}
+
+func TestReadMissingColumns(t *testing.T) {
+	_, err := Read(Cronometer, strings.NewReader("Day,Food Name\n2024-03-15,Rice\n"), 1)
+	if err == nil || !strings.Contains(err.Error(), "Energy (kcal)") {
+		t.Errorf("Erreur = %v, attendu une erreur citant les colonnes manquantes", err)
+	}
+}
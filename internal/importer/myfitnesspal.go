@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// mfpMicronutrients associe les colonnes de MyFitnessPal aux micronutriments
+// suivis. Les vitamines, le calcium et le fer, exprimés en pourcentage des
+// apports journaliers, ne sont pas repris.
+var mfpMicronutrients = map[string]fdc.NutrientKey{
+	"Sodium (mg)":   fdc.Sodium,
+	"Sugar":         fdc.Sugars,
+	"Saturated Fat": fdc.SaturatedFat,
+	"Cholesterol":   fdc.Cholesterol,
+	"Potassium":     fdc.Potassium,
+}
+
+// ReadMyFitnessPal lit l'export « Nutrition Summary » de MyFitnessPal.
+// Ce fichier contient une ligne par repas et par jour, sans le détail des
+// aliments : chaque ligne devient une entrée nommée d'après le repas.
+func ReadMyFitnessPal(r io.Reader, userID int) (*Result, error) {
+	required := []string{"Date", "Meal", "Calories", "Fat (g)", "Carbohydrates (g)", "Protein (g)"}
+
+	return readRecords(r, required, func(cols columns, record []string) (database.Meal, error) {
+		meal := database.Meal{UserID: userID}
+
+		date, err := time.ParseInLocation("2006-01-02", cols.get(record, "Date"), time.Local)
+		if err != nil {
+			return meal, fmt.Errorf("date invalide: %q", cols.get(record, "Date"))
+		}
+		meal.MealDate = date
+
+		mealName := cols.get(record, "Meal")
+		mealType, ok := database.MapMealType(mealName)
+		if !ok {
+			return meal, fmt.Errorf("repas inconnu: %q", mealName)
+		}
+		meal.MealType = string(mealType)
+		meal.FoodName = "MyFitnessPal - " + mealName
+
+		values := map[string]*float64{
+			"Calories":          &meal.Calories,
+			"Fat (g)":           &meal.Fats,
+			"Carbohydrates (g)": &meal.Carbs,
+			"Protein (g)":       &meal.Proteins,
+			"Fiber":             &meal.Fiber,
+		}
+		for name, value := range values {
+			if *value, err = cols.float(record, name); err != nil {
+				return meal, err
+			}
+		}
+		if meal.Micronutrients, err = cols.micronutrients(record, mfpMicronutrients); err != nil {
+			return meal, err
+		}
+
+		return meal, nil
+	})
+}
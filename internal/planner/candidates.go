@@ -0,0 +1,70 @@
+package planner
+
+import (
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+	"github.com/frachea/macro-tracker/internal/report"
+)
+
+// FromHistory construit les candidats à partir des aliments les plus souvent
+// consommés, en gardant au plus perMeal aliments par type de repas. Les types
+// de repas du CLI (petit-dejeuner, diner...) sont convertis.
+func FromHistory(foods []database.FrequentFood, perMeal int) []Candidate {
+	counts := make(map[database.MealType]int)
+	seen := make(map[database.MealType]map[int]bool)
+
+	var candidates []Candidate
+	for _, food := range foods {
+		mealType := database.MealType(food.MealType)
+		if !mealType.IsValid() {
+			var ok bool
+			if mealType, ok = database.MapMealType(food.MealType); !ok {
+				continue
+			}
+		}
+		if counts[mealType] >= perMeal {
+			continue
+		}
+		if seen[mealType] == nil {
+			seen[mealType] = make(map[int]bool)
+		}
+		if seen[mealType][food.FoodID] {
+			continue
+		}
+		seen[mealType][food.FoodID] = true
+		counts[mealType]++
+
+		candidates = append(candidates, Candidate{
+			FoodID:   food.FoodID,
+			FoodName: food.FoodName,
+			MealType: mealType,
+			Per100g: report.Totals{
+				Proteins: food.Proteins,
+				Carbs:    food.Carbs,
+				Fats:     food.Fats,
+				Calories: food.Calories,
+				Fiber:    food.Fiber,
+			},
+			Micronutrients: food.Micronutrients,
+		})
+	}
+	return candidates
+}
+
+// FromFood construit un candidat à partir d'un aliment FDC
+func FromFood(food *fdc.Food, mealType database.MealType) Candidate {
+	proteins, carbs, fats, calories, fiber := food.MacrosFor(100)
+	return Candidate{
+		FoodID:   food.FdcID,
+		FoodName: food.Description,
+		MealType: mealType,
+		Per100g: report.Totals{
+			Proteins: proteins,
+			Carbs:    carbs,
+			Fats:     fats,
+			Calories: calories,
+			Fiber:    fiber,
+		},
+		Micronutrients: food.MicronutrientsFor(100),
+	}
+}
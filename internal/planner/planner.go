@@ -0,0 +1,253 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/report"
+)
+
+const (
+	// DefaultMaxGrams est la quantité maximale d'un aliment sans contrainte explicite
+	DefaultMaxGrams = 400.0
+
+	maxIterations = 2000
+	tolerance     = 1e-6
+
+	// mealShareWeight pondère le respect de la répartition des calories
+	// entre les repas par rapport au respect des objectifs de la journée
+	mealShareWeight = 0.5
+)
+
+// DefaultCalorieShares est la répartition des calories utilisée pour les
+// types de repas sans contrainte explicite
+var DefaultCalorieShares = map[database.MealType]float64{
+	database.Breakfast: 0.25,
+	database.Snack1:    0.05,
+	database.Lunch:     0.35,
+	database.Snack2:    0.05,
+	database.Dinner:    0.30,
+}
+
+// nutrientWeights pondère l'écart relatif de chaque nutriment à son objectif
+var nutrientWeights = report.Totals{
+	Calories: 2,
+	Proteins: 1.5,
+	Carbs:    1,
+	Fats:     1,
+	Fiber:    0.5,
+}
+
+// Candidate est un aliment pouvant être placé dans un type de repas
+type Candidate struct {
+	FoodID   int               `json:"food_id"`
+	FoodName string            `json:"food_name"`
+	MealType database.MealType `json:"meal_type"`
+	Per100g  report.Totals     `json:"per_100g"`
+	MinGrams float64           `json:"min_grams"`
+	MaxGrams float64           `json:"max_grams"`
+	// Micronutrients sont les micronutriments pour 100g, reportés sur les
+	// éléments de la journée générée sans intervenir dans l'optimisation
+	Micronutrients database.Micronutrients `json:"micronutrients,omitempty"`
+}
+
+// MealConstraint fixe la part des calories journalières d'un type de repas
+type MealConstraint struct {
+	MealType     database.MealType `json:"meal_type"`
+	CalorieShare float64           `json:"calorie_share"`
+}
+
+// Generate calcule les quantités en grammes des candidats qui rapprochent le
+// plus la journée des objectifs. Le problème est résolu comme un moindre
+// carré borné sur les écarts relatifs aux objectifs de chaque nutriment et à
+// la part des calories de chaque repas, par descente de coordonnées.
+// Les aliments dont la quantité optimale est nulle ne sont pas renvoyés.
+func Generate(targets report.Targets, candidates []Candidate, constraints []MealConstraint) ([]database.MealPlanItem, error) {
+	if !targets.IsSet() {
+		return nil, errors.New("aucun objectif calorique défini")
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("aucun aliment candidat")
+	}
+
+	lower := make([]float64, len(candidates))
+	upper := make([]float64, len(candidates))
+	for j, candidate := range candidates {
+		if !candidate.MealType.IsValid() {
+			return nil, fmt.Errorf("type de repas invalide pour %s: %q", candidate.FoodName, candidate.MealType)
+		}
+		lower[j] = math.Max(candidate.MinGrams, 0) / 100
+		upper[j] = DefaultMaxGrams / 100
+		if candidate.MaxGrams > 0 {
+			upper[j] = candidate.MaxGrams / 100
+		}
+		if lower[j] > upper[j] {
+			return nil, fmt.Errorf("quantité minimale supérieure à la maximale pour %s", candidate.FoodName)
+		}
+	}
+
+	rows := buildRows(targets, candidates, calorieShares(candidates, constraints))
+	x := solve(rows, lower, upper)
+
+	items := make([]database.MealPlanItem, 0, len(candidates))
+	for j, candidate := range candidates {
+		grams := math.Round(x[j] * 100)
+		if grams <= 0 {
+			continue
+		}
+		ratio := grams / 100
+		items = append(items, database.MealPlanItem{
+			MealType: candidate.MealType,
+			FoodID:   candidate.FoodID,
+			FoodName: candidate.FoodName,
+			Amount:   grams,
+			Proteins: candidate.Per100g.Proteins * ratio,
+			Carbs:    candidate.Per100g.Carbs * ratio,
+			Fats:     candidate.Per100g.Fats * ratio,
+			Calories: candidate.Per100g.Calories * ratio,
+			Fiber:    candidate.Per100g.Fiber * ratio,
+
+			Micronutrients: database.Micronutrients(nil).Add(candidate.Micronutrients, ratio),
+		})
+	}
+
+	return items, nil
+}
+
+// calorieShares renvoie la part des calories de chaque type de repas présent
+// parmi les candidats, normalisée pour totaliser 1
+func calorieShares(candidates []Candidate, constraints []MealConstraint) map[database.MealType]float64 {
+	explicit := make(map[database.MealType]float64)
+	for _, constraint := range constraints {
+		if constraint.CalorieShare > 0 {
+			explicit[constraint.MealType] = constraint.CalorieShare
+		}
+	}
+
+	shares := make(map[database.MealType]float64)
+	var total float64
+	for _, candidate := range candidates {
+		if _, ok := shares[candidate.MealType]; ok {
+			continue
+		}
+		share, ok := explicit[candidate.MealType]
+		if !ok {
+			share = DefaultCalorieShares[candidate.MealType]
+		}
+		shares[candidate.MealType] = share
+		total += share
+	}
+
+	if total > 0 {
+		for mealType := range shares {
+			shares[mealType] /= total
+		}
+	}
+	return shares
+}
+
+// row est un terme weight * (coefs · x - target)² de la fonction objectif
+type row struct {
+	coefs  []float64
+	target float64
+	weight float64
+}
+
+func buildRows(targets report.Targets, candidates []Candidate, shares map[database.MealType]float64) []row {
+	goal := report.Totals{
+		Proteins: targets.Proteins,
+		Carbs:    targets.Carbs,
+		Fats:     targets.Fats,
+		Calories: targets.Calories,
+		Fiber:    targets.Fiber,
+	}
+	nutrients := []func(report.Totals) float64{
+		func(t report.Totals) float64 { return t.Calories },
+		func(t report.Totals) float64 { return t.Proteins },
+		func(t report.Totals) float64 { return t.Carbs },
+		func(t report.Totals) float64 { return t.Fats },
+		func(t report.Totals) float64 { return t.Fiber },
+	}
+
+	var rows []row
+	for _, get := range nutrients {
+		target := get(goal)
+		if target <= 0 {
+			continue
+		}
+		r := row{coefs: make([]float64, len(candidates)), target: 1, weight: get(nutrientWeights)}
+		for j, candidate := range candidates {
+			r.coefs[j] = get(candidate.Per100g) / target
+		}
+		rows = append(rows, r)
+	}
+
+	for mealType, share := range shares {
+		if share <= 0 {
+			continue
+		}
+		r := row{coefs: make([]float64, len(candidates)), target: share, weight: mealShareWeight}
+		for j, candidate := range candidates {
+			if candidate.MealType == mealType {
+				r.coefs[j] = candidate.Per100g.Calories / targets.Calories
+			}
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+// solve minimise la somme des termes par descente de coordonnées, chaque
+// variable étant bornée par lower et upper. Le problème étant convexe, la
+// méthode converge vers l'optimum.
+func solve(rows []row, lower, upper []float64) []float64 {
+	x := make([]float64, len(lower))
+	copy(x, lower)
+
+	residuals := make([]float64, len(rows))
+	for i, r := range rows {
+		residuals[i] = -r.target
+		for j, c := range r.coefs {
+			residuals[i] += c * x[j]
+		}
+	}
+
+	for iter := 0; iter < maxIterations; iter++ {
+		maxDelta := 0.0
+		for j := range x {
+			var num, den float64
+			for i, r := range rows {
+				c := r.coefs[j]
+				if c == 0 {
+					continue
+				}
+				// Résidu sans la contribution de x[j]
+				rest := residuals[i] - c*x[j]
+				num -= r.weight * c * rest
+				den += r.weight * c * c
+			}
+			if den == 0 {
+				continue
+			}
+
+			value := math.Min(math.Max(num/den, lower[j]), upper[j])
+			delta := value - x[j]
+			if delta == 0 {
+				continue
+			}
+			for i, r := range rows {
+				residuals[i] += r.coefs[j] * delta
+			}
+			x[j] = value
+			maxDelta = math.Max(maxDelta, math.Abs(delta))
+		}
+		if maxDelta < tolerance {
+			break
+		}
+	}
+
+	return x
+}
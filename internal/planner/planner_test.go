@@ -0,0 +1,164 @@
+package planner
+
+import (
+	"math"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/report"
+)
+
+var testCandidates = []Candidate{
+	{FoodID: 1, FoodName: "Oats", MealType: database.Breakfast, Per100g: report.Totals{Proteins: 13, Carbs: 68, Fats: 7, Calories: 379, Fiber: 10}},
+	{FoodID: 2, FoodName: "Milk", MealType: database.Breakfast, Per100g: report.Totals{Proteins: 3.3, Carbs: 4.8, Fats: 3.2, Calories: 61}},
+	{FoodID: 3, FoodName: "Chicken breast", MealType: database.Lunch, Per100g: report.Totals{Proteins: 31, Fats: 3.6, Calories: 165}},
+	{FoodID: 4, FoodName: "Rice", MealType: database.Lunch, Per100g: report.Totals{Proteins: 2.7, Carbs: 28, Fats: 0.3, Calories: 130, Fiber: 0.4}},
+	{FoodID: 5, FoodName: "Salmon", MealType: database.Dinner, Per100g: report.Totals{Proteins: 20, Fats: 13, Calories: 208}},
+	{FoodID: 6, FoodName: "Potato", MealType: database.Dinner, Per100g: report.Totals{Proteins: 2, Carbs: 17, Fats: 0.1, Calories: 77, Fiber: 2.2}},
+	{FoodID: 7, FoodName: "Broccoli", MealType: database.Dinner, Per100g: report.Totals{Proteins: 2.8, Carbs: 7, Fats: 0.4, Calories: 34, Fiber: 2.6}},
+}
+
+func TestGenerateHitsTargets(t *testing.T) {
+	targets := report.Targets{Calories: 2200, Proteins: 150, Carbs: 240, Fats: 70, Fiber: 30}
+
+	items, err := Generate(targets, testCandidates, nil)
+	if err != nil {
+		t.Fatalf("Generate() erreur = %v", err)
+	}
+
+	var totals report.Totals
+	byMeal := make(map[database.MealType]float64)
+	for _, item := range items {
+		if item.Amount <= 0 || item.Amount > DefaultMaxGrams {
+			t.Errorf("%s: quantité %v hors bornes", item.FoodName, item.Amount)
+		}
+		totals.AddPlanItem(item)
+		byMeal[item.MealType] += item.Calories
+	}
+
+	if math.Abs(totals.Calories-targets.Calories)/targets.Calories > 0.05 {
+		t.Errorf("Calories = %.0f, attendu %.0f à 5%% près", totals.Calories, targets.Calories)
+	}
+	if math.Abs(totals.Proteins-targets.Proteins)/targets.Proteins > 0.10 {
+		t.Errorf("Protéines = %.1f, attendu %.1f à 10%% près", totals.Proteins, targets.Proteins)
+	}
+	if byMeal[database.Breakfast] == 0 || byMeal[database.Lunch] == 0 || byMeal[database.Dinner] == 0 {
+		t.Errorf("Calories par repas = %v, attendu des calories à chaque repas", byMeal)
+	}
+}
+
+func TestGenerateCarriesMicronutrients(t *testing.T) {
+	targets := report.Targets{Calories: 600, Proteins: 40}
+	candidates := []Candidate{{
+		FoodID:         5,
+		FoodName:       "Salmon",
+		MealType:       database.Dinner,
+		Per100g:        report.Totals{Proteins: 20, Fats: 13, Calories: 208},
+		Micronutrients: database.Micronutrients{"vitamin_d": 11, "sodium": 59},
+	}}
+
+	items, err := Generate(targets, candidates, nil)
+	if err != nil {
+		t.Fatalf("Generate() erreur = %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Éléments = %+v, attendu 1 élément", items)
+	}
+
+	ratio := items[0].Amount / 100
+	for key, per100g := range candidates[0].Micronutrients {
+		if got := items[0].Micronutrients[key]; math.Abs(got-per100g*ratio) > 1e-9 {
+			t.Errorf("Micronutriment %s = %v, attendu %v pour %.0fg", key, got, per100g*ratio, items[0].Amount)
+		}
+	}
+}
+
+func TestGenerateRespectsBounds(t *testing.T) {
+	candidates := []Candidate{
+		{FoodID: 1, FoodName: "Chicken breast", MealType: database.Lunch, Per100g: report.Totals{Proteins: 31, Fats: 3.6, Calories: 165}, MaxGrams: 150},
+		{FoodID: 2, FoodName: "Rice", MealType: database.Lunch, Per100g: report.Totals{Proteins: 2.7, Carbs: 28, Fats: 0.3, Calories: 130}, MinGrams: 250},
+	}
+	targets := report.Targets{Calories: 1000, Proteins: 100}
+
+	items, err := Generate(targets, candidates, []MealConstraint{{MealType: database.Lunch, CalorieShare: 1}})
+	if err != nil {
+		t.Fatalf("Generate() erreur = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("%d aliments, attendu 2", len(items))
+	}
+	if items[0].Amount != 150 {
+		t.Errorf("Poulet = %vg, attendu la borne maximale de 150g", items[0].Amount)
+	}
+	if items[1].Amount < 250 {
+		t.Errorf("Riz = %vg, attendu au moins 250g", items[1].Amount)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    report.Targets
+		candidates []Candidate
+	}{
+		{name: "Sans objectifs", targets: report.Targets{}, candidates: testCandidates},
+		{name: "Sans candidats", targets: report.Targets{Calories: 2000}},
+		{name: "Type de repas invalide", targets: report.Targets{Calories: 2000}, candidates: []Candidate{{FoodName: "Rice", MealType: "brunch"}}},
+		{name: "Bornes incohérentes", targets: report.Targets{Calories: 2000}, candidates: []Candidate{{FoodName: "Rice", MealType: database.Lunch, MinGrams: 300, MaxGrams: 100}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(tt.targets, tt.candidates, nil); err == nil {
+				t.Error("Une erreur était attendue")
+			}
+		})
+	}
+}
+
+func TestCalorieShares(t *testing.T) {
+	candidates := []Candidate{
+		{MealType: database.Breakfast},
+		{MealType: database.Dinner},
+		{MealType: database.Dinner},
+	}
+
+	shares := calorieShares(candidates, []MealConstraint{{MealType: database.Dinner, CalorieShare: 0.75}})
+
+	if len(shares) != 2 {
+		t.Fatalf("Répartition = %v, attendu 2 types de repas", shares)
+	}
+	if math.Abs(shares[database.Breakfast]-0.25) > 1e-9 || math.Abs(shares[database.Dinner]-0.75) > 1e-9 {
+		t.Errorf("Répartition = %v, attendu breakfast 0.25 et dinner 0.75", shares)
+	}
+}
+
+func TestFromHistory(t *testing.T) {
+	foods := []database.FrequentFood{
+		{FoodID: 1, FoodName: "Oats", MealType: "petit-dejeuner", Uses: 10, Calories: 379},
+		{FoodID: 1, FoodName: "Oats", MealType: "breakfast", Uses: 4, Calories: 379},
+		{FoodID: 2, FoodName: "Milk", MealType: "breakfast", Uses: 3, Calories: 61},
+		{FoodID: 3, FoodName: "Banana", MealType: "breakfast", Uses: 2, Calories: 89},
+		{FoodID: 4, FoodName: "Rice", MealType: "dejeuner", Uses: 8, Calories: 130},
+		{FoodID: 5, FoodName: "Cake", MealType: "brunch", Uses: 1, Calories: 400},
+	}
+
+	candidates := FromHistory(foods, 2)
+
+	expected := []struct {
+		foodID   int
+		mealType database.MealType
+	}{
+		{1, database.Breakfast},
+		{2, database.Breakfast},
+		{4, database.Lunch},
+	}
+	if len(candidates) != len(expected) {
+		t.Fatalf("Candidats = %+v, attendu %d candidats", candidates, len(expected))
+	}
+	for i, e := range expected {
+		if candidates[i].FoodID != e.foodID || candidates[i].MealType != e.mealType {
+			t.Errorf("Candidat %d = %d/%s, attendu %d/%s", i, candidates[i].FoodID, candidates[i].MealType, e.foodID, e.mealType)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+// mealTypeOrder est l'ordre d'affichage des types de repas d'une journée type
+var mealTypeOrder = []database.MealType{
+	database.Breakfast,
+	database.Snack1,
+	database.Lunch,
+	database.Snack2,
+	database.Dinner,
+}
+
+// MealTypeTotals est le total d'un type de repas dans une journée type
+type MealTypeTotals struct {
+	MealType database.MealType `json:"meal_type"`
+	Totals   Totals            `json:"totals"`
+}
+
+// PlanSummary compare les apports d'une journée type aux objectifs de son propriétaire
+type PlanSummary struct {
+	Totals     Totals           `json:"totals"`
+	ByMealType []MealTypeTotals `json:"by_meal_type"`
+	Targets    *Targets         `json:"targets,omitempty"`
+	Deviation  *Totals          `json:"deviation,omitempty"`
+	Percent    *Totals          `json:"percent,omitempty"`
+	Warnings   []string         `json:"warnings"`
+}
+
+// AddPlanItem ajoute les nutriments d'un élément de journée type au total
+func (t *Totals) AddPlanItem(item database.MealPlanItem) {
+	t.Proteins += item.Proteins
+	t.Carbs += item.Carbs
+	t.Fats += item.Fats
+	t.Calories += item.Calories
+	t.Fiber += item.Fiber
+}
+
+// Minus renvoie l'écart entre les totaux et les objectifs, en grammes et kcal
+func (t Totals) Minus(targets Targets) Totals {
+	return Totals{
+		Proteins: t.Proteins - targets.Proteins,
+		Carbs:    t.Carbs - targets.Carbs,
+		Fats:     t.Fats - targets.Fats,
+		Calories: t.Calories - targets.Calories,
+		Fiber:    t.Fiber - targets.Fiber,
+	}
+}
+
+// SummarizePlan calcule les totaux d'une journée type, par type de repas et
+// au total. Avec des objectifs définis, un avertissement est émis lorsque les
+// protéines sont inférieures de plus de GoalTolerance à l'objectif ou que les
+// calories le dépassent de plus de GoalTolerance.
+func SummarizePlan(items []database.MealPlanItem, targets Targets) PlanSummary {
+	summary := PlanSummary{
+		ByMealType: []MealTypeTotals{},
+		Warnings:   []string{},
+	}
+
+	byType := make(map[database.MealType]*Totals)
+	var others []database.MealType
+	for _, item := range items {
+		totals, ok := byType[item.MealType]
+		if !ok {
+			totals = &Totals{}
+			byType[item.MealType] = totals
+			if !item.MealType.IsValid() {
+				others = append(others, item.MealType)
+			}
+		}
+		totals.AddPlanItem(item)
+		summary.Totals.AddPlanItem(item)
+	}
+
+	ordered := make([]database.MealType, 0, len(mealTypeOrder)+len(others))
+	ordered = append(ordered, mealTypeOrder...)
+	ordered = append(ordered, others...)
+	for _, mealType := range ordered {
+		if totals, ok := byType[mealType]; ok {
+			summary.ByMealType = append(summary.ByMealType, MealTypeTotals{MealType: mealType, Totals: *totals})
+		}
+	}
+
+	if !targets.IsSet() {
+		return summary
+	}
+
+	deviation := summary.Totals.Minus(targets)
+	percent := summary.Totals.PercentOf(targets)
+	summary.Targets = &targets
+	summary.Deviation = &deviation
+	summary.Percent = &percent
+
+	if targets.Proteins > 0 && summary.Totals.Proteins < targets.Proteins*(1-GoalTolerance) {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf(
+			"Protéines insuffisantes: %.1fg pour un objectif de %.1fg", summary.Totals.Proteins, targets.Proteins))
+	}
+	if summary.Totals.Calories > targets.Calories*(1+GoalTolerance) {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf(
+			"Calories excessives: %.0f kcal pour un objectif de %.0f kcal", summary.Totals.Calories, targets.Calories))
+	}
+
+	return summary
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+func TestSummarizePlan(t *testing.T) {
+	items := []database.MealPlanItem{
+		{MealType: database.Dinner, Proteins: 40, Carbs: 60, Fats: 20, Calories: 700, Fiber: 6},
+		{MealType: database.Breakfast, Proteins: 20, Carbs: 80, Fats: 10, Calories: 500, Fiber: 8},
+		{MealType: database.Dinner, Proteins: 5, Carbs: 30, Fats: 15, Calories: 300, Fiber: 4},
+		{MealType: database.Lunch, Proteins: 45, Carbs: 100, Fats: 25, Calories: 800, Fiber: 10},
+	}
+
+	tests := []struct {
+		name             string
+		targets          Targets
+		expectedWarnings int
+	}{
+		{name: "Sans objectifs", targets: Targets{}, expectedWarnings: 0},
+		{name: "Objectifs atteints", targets: Targets{Calories: 2300, Proteins: 110}, expectedWarnings: 0},
+		{name: "Protéines insuffisantes", targets: Targets{Calories: 2300, Proteins: 150}, expectedWarnings: 1},
+		{name: "Protéines et calories hors objectifs", targets: Targets{Calories: 1800, Proteins: 150}, expectedWarnings: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := SummarizePlan(items, tt.targets)
+
+			expectedTotals := Totals{Proteins: 110, Carbs: 270, Fats: 70, Calories: 2300, Fiber: 28}
+			if summary.Totals != expectedTotals {
+				t.Errorf("Totaux = %+v, attendu %+v", summary.Totals, expectedTotals)
+			}
+
+			order := []database.MealType{database.Breakfast, database.Lunch, database.Dinner}
+			if len(summary.ByMealType) != len(order) {
+				t.Fatalf("%d types de repas, attendu %d", len(summary.ByMealType), len(order))
+			}
+			for i, mealType := range order {
+				if summary.ByMealType[i].MealType != mealType {
+					t.Errorf("Type de repas %d = %s, attendu %s", i, summary.ByMealType[i].MealType, mealType)
+				}
+			}
+			if summary.ByMealType[2].Totals.Calories != 1000 {
+				t.Errorf("Calories du dîner = %v, attendu 1000", summary.ByMealType[2].Totals.Calories)
+			}
+
+			if len(summary.Warnings) != tt.expectedWarnings {
+				t.Errorf("Avertissements = %v, attendu %d", summary.Warnings, tt.expectedWarnings)
+			}
+
+			if !tt.targets.IsSet() {
+				if summary.Deviation != nil {
+					t.Error("Écart inattendu sans objectifs")
+				}
+				return
+			}
+			if summary.Deviation == nil || summary.Deviation.Calories != 2300-tt.targets.Calories {
+				t.Errorf("Écart = %+v, attendu %v kcal", summary.Deviation, 2300-tt.targets.Calories)
+			}
+		})
+	}
+}
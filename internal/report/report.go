@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+// Targets représente les objectifs nutritionnels journaliers stockés
+// dans le champ target_macros d'un utilisateur
+type Targets struct {
+	Calories float64 `json:"calories"`
+	Proteins float64 `json:"proteins"`
+	Carbs    float64 `json:"carbs"`
+	Fats     float64 `json:"fats"`
+	Fiber    float64 `json:"fiber"`
+}
+
+// ParseTargets décode les objectifs d'un utilisateur. Un JSON vide
+// donne des objectifs nuls sans erreur.
+func ParseTargets(raw json.RawMessage) (Targets, error) {
+	var targets Targets
+	if len(raw) == 0 {
+		return targets, nil
+	}
+	err := json.Unmarshal(raw, &targets)
+	return targets, err
+}
+
+// IsSet indique si des objectifs ont été définis
+func (t Targets) IsSet() bool {
+	return t.Calories > 0
+}
+
+// Totals regroupe les cinq nutriments suivis par l'application
+type Totals struct {
+	Proteins float64 `json:"proteins"`
+	Carbs    float64 `json:"carbs"`
+	Fats     float64 `json:"fats"`
+	Calories float64 `json:"calories"`
+	Fiber    float64 `json:"fiber"`
+}
+
+// AddMeal ajoute les nutriments d'un repas au total
+func (t *Totals) AddMeal(meal database.Meal) {
+	t.Proteins += meal.Proteins
+	t.Carbs += meal.Carbs
+	t.Fats += meal.Fats
+	t.Calories += meal.Calories
+	t.Fiber += meal.Fiber
+}
+
+// Add ajoute d'autres totaux au total
+func (t *Totals) Add(other Totals) {
+	t.Proteins += other.Proteins
+	t.Carbs += other.Carbs
+	t.Fats += other.Fats
+	t.Calories += other.Calories
+	t.Fiber += other.Fiber
+}
+
+// Scale renvoie les totaux multipliés par ratio
+func (t Totals) Scale(ratio float64) Totals {
+	return Totals{
+		Proteins: t.Proteins * ratio,
+		Carbs:    t.Carbs * ratio,
+		Fats:     t.Fats * ratio,
+		Calories: t.Calories * ratio,
+		Fiber:    t.Fiber * ratio,
+	}
+}
+
+// PercentOf calcule le pourcentage atteint pour chaque objectif.
+// Un objectif nul donne 0%.
+func (t Totals) PercentOf(targets Targets) Totals {
+	return Totals{
+		Proteins: percent(t.Proteins, targets.Proteins),
+		Carbs:    percent(t.Carbs, targets.Carbs),
+		Fats:     percent(t.Fats, targets.Fats),
+		Calories: percent(t.Calories, targets.Calories),
+		Fiber:    percent(t.Fiber, targets.Fiber),
+	}
+}
+
+func percent(value, target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return value / target * 100
+}
+
+// MealGroup regroupe les aliments consommés pour un même type de repas
+type MealGroup struct {
+	MealType string          `json:"meal_type"`
+	Entries  []database.Meal `json:"entries"`
+	Totals   Totals          `json:"totals"`
+}
+
+// DailyReport est le bilan nutritionnel d'une journée
+type DailyReport struct {
+	Date           string                  `json:"date"`
+	Meals          []MealGroup             `json:"meals"`
+	Totals         Totals                  `json:"totals"`
+	Micronutrients database.Micronutrients `json:"micronutrients,omitempty"`
+	Targets        *Targets                `json:"targets,omitempty"`
+	Percent        *Totals                 `json:"percent,omitempty"`
+}
+
+// Daily construit le bilan d'une journée à partir de ses repas. Les groupes
+// suivent l'ordre chronologique du premier aliment de chaque type de repas.
+func Daily(date time.Time, meals []database.Meal, targets Targets) DailyReport {
+	report := DailyReport{
+		Date:  date.Format("2006-01-02"),
+		Meals: []MealGroup{},
+	}
+
+	groups := make(map[string]int)
+	for _, meal := range meals {
+		idx, ok := groups[meal.MealType]
+		if !ok {
+			idx = len(report.Meals)
+			groups[meal.MealType] = idx
+			report.Meals = append(report.Meals, MealGroup{MealType: meal.MealType})
+		}
+		report.Meals[idx].Entries = append(report.Meals[idx].Entries, meal)
+		report.Meals[idx].Totals.AddMeal(meal)
+		report.Totals.AddMeal(meal)
+		report.Micronutrients = report.Micronutrients.Add(meal.Micronutrients, 1)
+	}
+
+	if targets.IsSet() {
+		percent := report.Totals.PercentOf(targets)
+		report.Targets = &targets
+		report.Percent = &percent
+	}
+
+	return report
+}
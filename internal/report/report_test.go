@@ -0,0 +1,107 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+func TestParseTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected Targets
+		wantErr  bool
+	}{
+		{
+			name:     "Objectifs complets",
+			raw:      `{"calories": 2000, "proteins": 150, "carbs": 200, "fats": 70, "fiber": 30}`,
+			expected: Targets{Calories: 2000, Proteins: 150, Carbs: 200, Fats: 70, Fiber: 30},
+		},
+		{
+			name:     "JSON vide",
+			raw:      "",
+			expected: Targets{},
+		},
+		{
+			name:    "JSON invalide",
+			raw:     "{",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ParseTargets([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTargets() erreur = %v, attendu erreur: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && targets != tt.expected {
+				t.Errorf("ParseTargets() = %+v, attendu %+v", targets, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDaily(t *testing.T) {
+	date := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	meals := []database.Meal{
+		{ID: 1, MealType: "breakfast", FoodName: "Flocons d'avoine", Amount: 80, Proteins: 10, Carbs: 50, Fats: 5, Calories: 300, Fiber: 8,
+			Micronutrients: database.Micronutrients{"iron": 3.2, "sodium": 5}},
+		{ID: 2, MealType: "lunch", FoodName: "Poulet", Amount: 150, Proteins: 45, Carbs: 0, Fats: 5, Calories: 250, Fiber: 0,
+			Micronutrients: database.Micronutrients{"sodium": 110}},
+		{ID: 3, MealType: "breakfast", FoodName: "Banane", Amount: 120, Proteins: 1, Carbs: 25, Fats: 0, Calories: 100, Fiber: 3},
+	}
+	targets := Targets{Calories: 2000, Proteins: 112, Carbs: 0, Fats: 50, Fiber: 22}
+
+	report := Daily(date, meals, targets)
+
+	if report.Date != "2024-03-15" {
+		t.Errorf("Date = %s, attendu 2024-03-15", report.Date)
+	}
+	if len(report.Meals) != 2 {
+		t.Fatalf("Nombre de groupes = %d, attendu 2", len(report.Meals))
+	}
+	if report.Meals[0].MealType != "breakfast" || len(report.Meals[0].Entries) != 2 {
+		t.Errorf("Premier groupe = %s avec %d aliments, attendu breakfast avec 2 aliments",
+			report.Meals[0].MealType, len(report.Meals[0].Entries))
+	}
+	if report.Meals[0].Totals.Calories != 400 {
+		t.Errorf("Calories du petit-déjeuner = %v, attendu 400", report.Meals[0].Totals.Calories)
+	}
+
+	expectedTotals := Totals{Proteins: 56, Carbs: 75, Fats: 10, Calories: 650, Fiber: 11}
+	if report.Totals != expectedTotals {
+		t.Errorf("Totaux = %+v, attendu %+v", report.Totals, expectedTotals)
+	}
+
+	if report.Micronutrients["sodium"] != 115 || report.Micronutrients["iron"] != 3.2 {
+		t.Errorf("Micronutriments = %v, attendu sodium 115 et fer 3.2", report.Micronutrients)
+	}
+
+	if report.Percent == nil {
+		t.Fatal("Pourcentages absents alors que des objectifs sont définis")
+	}
+	if math.Abs(report.Percent.Calories-32.5) > 0.01 {
+		t.Errorf("Pourcentage calories = %v, attendu 32.5", report.Percent.Calories)
+	}
+	if math.Abs(report.Percent.Proteins-50) > 0.01 {
+		t.Errorf("Pourcentage protéines = %v, attendu 50", report.Percent.Proteins)
+	}
+	if report.Percent.Carbs != 0 {
+		t.Errorf("Pourcentage glucides = %v, attendu 0 pour un objectif nul", report.Percent.Carbs)
+	}
+}
+
+func TestDailyWithoutTargets(t *testing.T) {
+	report := Daily(time.Now(), nil, Targets{})
+
+	if report.Targets != nil || report.Percent != nil {
+		t.Error("Objectifs et pourcentages devraient être absents sans objectif défini")
+	}
+	if report.Meals == nil || len(report.Meals) != 0 {
+		t.Errorf("Groupes = %v, attendu une liste vide", report.Meals)
+	}
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+// GoalTolerance est l'écart relatif aux calories cibles en deçà duquel
+// une journée est considérée conforme à l'objectif
+const GoalTolerance = 0.10
+
+// Period désigne la durée couverte par un bilan
+type Period string
+
+const (
+	Week  Period = "week"
+	Month Period = "month"
+)
+
+// Bounds renvoie le premier et le dernier jour de la période contenant ref.
+// Les semaines commencent le lundi.
+func (p Period) Bounds(ref time.Time) (start, end time.Time, err error) {
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	switch p {
+	case Week:
+		offset := (int(day.Weekday()) + 6) % 7
+		start = day.AddDate(0, 0, -offset)
+		end = start.AddDate(0, 0, 6)
+	case Month:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		end = start.AddDate(0, 1, -1)
+	default:
+		return start, end, fmt.Errorf("période inconnue: %s (attendu: week ou month)", p)
+	}
+	return start, end, nil
+}
+
+// DaySummary est le total d'une journée du bilan
+type DaySummary struct {
+	Date      string  `json:"date"`
+	Totals    Totals  `json:"totals"`
+	Deviation float64 `json:"deviation"`
+	OnTarget  bool    `json:"on_target"`
+}
+
+// Summary est le bilan d'une période de plusieurs jours
+type Summary struct {
+	From                  string                  `json:"from"`
+	To                    string                  `json:"to"`
+	Days                  int                     `json:"days"`
+	LoggedDays            int                     `json:"logged_days"`
+	Average               Totals                  `json:"average"`
+	AverageMicronutrients database.Micronutrients `json:"average_micronutrients,omitempty"`
+	Targets               *Targets                `json:"targets,omitempty"`
+	DaysOnTarget          int                     `json:"days_on_target"`
+	BestDay               *DaySummary             `json:"best_day,omitempty"`
+	WorstDay              *DaySummary             `json:"worst_day,omitempty"`
+	Daily                 []DaySummary            `json:"daily"`
+}
+
+// Summarize calcule le bilan des repas compris entre start et end inclus.
+// Les moyennes portent sur les jours où au moins un repas a été saisi.
+// Avec des objectifs définis, l'écart de chaque jour est l'écart relatif
+// aux calories cibles, et les meilleur et pire jours sont ceux dont
+// l'écart absolu est respectivement le plus faible et le plus élevé.
+func Summarize(start, end time.Time, meals []database.Meal, targets Targets) Summary {
+	summary := Summary{
+		From:  start.Format("2006-01-02"),
+		To:    end.Format("2006-01-02"),
+		Days:  int(math.Round(end.Sub(start).Hours()/24)) + 1,
+		Daily: []DaySummary{},
+	}
+
+	byDay := make(map[string]*Totals)
+	var micronutrients database.Micronutrients
+	for _, meal := range meals {
+		micronutrients = micronutrients.Add(meal.Micronutrients, 1)
+
+		key := meal.MealDate.Format("2006-01-02")
+		totals, ok := byDay[key]
+		if !ok {
+			totals = &Totals{}
+			byDay[key] = totals
+		}
+		totals.AddMeal(meal)
+	}
+
+	keys := make([]string, 0, len(byDay))
+	for key := range byDay {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sum Totals
+	for _, key := range keys {
+		totals := *byDay[key]
+		day := DaySummary{Date: key, Totals: totals}
+		if targets.IsSet() {
+			day.Deviation = (totals.Calories - targets.Calories) / targets.Calories
+			day.OnTarget = math.Abs(day.Deviation) <= GoalTolerance
+		}
+		summary.Daily = append(summary.Daily, day)
+		sum.Add(totals)
+	}
+
+	summary.LoggedDays = len(summary.Daily)
+	if summary.LoggedDays == 0 {
+		return summary
+	}
+
+	n := float64(summary.LoggedDays)
+	summary.Average = sum.Scale(1 / n)
+	summary.AverageMicronutrients = database.Micronutrients(nil).Add(micronutrients, 1/n)
+
+	if !targets.IsSet() {
+		return summary
+	}
+
+	summary.Targets = &targets
+	best, worst := 0, 0
+	for i, day := range summary.Daily {
+		if day.OnTarget {
+			summary.DaysOnTarget++
+		}
+		if math.Abs(day.Deviation) < math.Abs(summary.Daily[best].Deviation) {
+			best = i
+		}
+		if math.Abs(day.Deviation) > math.Abs(summary.Daily[worst].Deviation) {
+			worst = i
+		}
+	}
+	bestDay, worstDay := summary.Daily[best], summary.Daily[worst]
+	summary.BestDay = &bestDay
+	summary.WorstDay = &worstDay
+
+	return summary
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/frachea/macro-tracker/internal/database"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	// Mercredi 13 mars 2024
+	ref := time.Date(2024, 3, 13, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		period        Period
+		expectedStart string
+		expectedEnd   string
+		wantErr       bool
+	}{
+		{name: "Semaine", period: Week, expectedStart: "2024-03-11", expectedEnd: "2024-03-17"},
+		{name: "Mois", period: Month, expectedStart: "2024-03-01", expectedEnd: "2024-03-31"},
+		{name: "Période inconnue", period: "year", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := tt.period.Bounds(ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bounds() erreur = %v, attendu erreur: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if start.Format("2006-01-02") != tt.expectedStart || end.Format("2006-01-02") != tt.expectedEnd {
+				t.Errorf("Bounds() = %s - %s, attendu %s - %s",
+					start.Format("2006-01-02"), end.Format("2006-01-02"), tt.expectedStart, tt.expectedEnd)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 6)
+	at := func(day, hour int) time.Time {
+		return start.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	}
+
+	meals := []database.Meal{
+		{MealDate: at(0, 8), Calories: 900, Proteins: 50},
+		{MealDate: at(0, 19), Calories: 1100, Proteins: 70},
+		{MealDate: at(2, 12), Calories: 2500, Proteins: 100},
+		{MealDate: at(4, 12), Calories: 1900, Proteins: 90},
+	}
+	targets := Targets{Calories: 2000, Proteins: 120}
+
+	summary := Summarize(start, end, meals, targets)
+
+	if summary.Days != 7 {
+		t.Errorf("Days = %d, attendu 7", summary.Days)
+	}
+	if summary.LoggedDays != 3 {
+		t.Errorf("LoggedDays = %d, attendu 3", summary.LoggedDays)
+	}
+	if math.Abs(summary.Average.Calories-2133.33) > 0.01 {
+		t.Errorf("Calories moyennes = %v, attendu 2133.33", summary.Average.Calories)
+	}
+	if math.Abs(summary.Average.Proteins-103.33) > 0.01 {
+		t.Errorf("Protéines moyennes = %v, attendu 103.33", summary.Average.Proteins)
+	}
+	if summary.DaysOnTarget != 2 {
+		t.Errorf("DaysOnTarget = %d, attendu 2", summary.DaysOnTarget)
+	}
+	if summary.BestDay == nil || summary.BestDay.Date != "2024-03-11" {
+		t.Errorf("BestDay = %+v, attendu 2024-03-11", summary.BestDay)
+	}
+	if summary.WorstDay == nil || summary.WorstDay.Date != "2024-03-13" {
+		t.Errorf("WorstDay = %+v, attendu 2024-03-13", summary.WorstDay)
+	}
+}
+
+func TestSummarizeWithoutData(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	summary := Summarize(start, start.AddDate(0, 1, -1), nil, Targets{Calories: 2000})
+
+	if summary.Days != 31 || summary.LoggedDays != 0 {
+		t.Errorf("Days = %d, LoggedDays = %d, attendu 31 et 0", summary.Days, summary.LoggedDays)
+	}
+	if summary.BestDay != nil || summary.WorstDay != nil {
+		t.Error("Aucun meilleur ou pire jour attendu sans données")
+	}
+}
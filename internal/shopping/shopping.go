@@ -0,0 +1,224 @@
+package shopping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+// UncategorizedLabel regroupe les aliments dont la catégorie est inconnue
+const UncategorizedLabel = "Autres"
+
+// Format désigne un format de liste de courses
+type Format string
+
+const (
+	Text     Format = "text"
+	Markdown Format = "md"
+	JSON     Format = "json"
+)
+
+// ParseFormat convertit un nom de format, insensible à la casse
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "text", "txt":
+		return Text, nil
+	case "md", "markdown":
+		return Markdown, nil
+	case "json":
+		return JSON, nil
+	}
+	return "", fmt.Errorf("format de liste inconnu: %s (attendu: text, md ou json)", name)
+}
+
+// ContentType renvoie le type MIME associé au format
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// PlanDays associe les éléments d'une journée type au nombre de jours où elle sera suivie
+type PlanDays struct {
+	Items []database.MealPlanItem
+	Days  int
+}
+
+// ParsePlanSpec lit une journée type sous la forme « id » ou « id:jours »
+func ParsePlanSpec(spec string) (planID, days int, err error) {
+	idStr, daysStr, found := strings.Cut(strings.TrimSpace(spec), ":")
+	planID, err = strconv.Atoi(idStr)
+	if err != nil || planID <= 0 {
+		return 0, 0, fmt.Errorf("journée type invalide: %q", spec)
+	}
+	days = 1
+	if found {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return 0, 0, fmt.Errorf("nombre de jours invalide: %q", spec)
+		}
+	}
+	return planID, days, nil
+}
+
+// Entry est un aliment à acheter
+type Entry struct {
+	FoodID   int     `json:"food_id"`
+	FoodName string  `json:"food_name"`
+	Grams    float64 `json:"grams"`
+}
+
+// Category regroupe les aliments d'une même catégorie FDC
+type Category struct {
+	Name    string  `json:"name"`
+	Entries []Entry `json:"entries"`
+}
+
+// List est une liste de courses groupée par catégorie
+type List struct {
+	Categories []Category `json:"categories"`
+}
+
+// entryKey identifie un aliment de la liste. Les recettes et les aliments
+// personnalisés, sans identifiant FDC, sont reconnus à leur nom.
+type entryKey struct {
+	foodID int
+	name   string
+}
+
+func keyOf(item database.MealPlanItem) entryKey {
+	if item.FoodID > 0 {
+		return entryKey{foodID: item.FoodID}
+	}
+	return entryKey{name: strings.ToLower(strings.TrimSpace(item.FoodName))}
+}
+
+// FoodIDs renvoie les identifiants FDC, sans doublon, des aliments des
+// journées types
+func FoodIDs(plans []PlanDays) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, plan := range plans {
+		for _, item := range plan.Items {
+			if item.FoodID > 0 && !seen[item.FoodID] {
+				seen[item.FoodID] = true
+				ids = append(ids, item.FoodID)
+			}
+		}
+	}
+	return ids
+}
+
+// Categories renvoie la catégorie FDC des aliments des journées types,
+// demandés en une fois à provider
+func Categories(ctx context.Context, provider fdc.Provider, plans []PlanDays) (map[int]string, error) {
+	categories := make(map[int]string)
+	ids := FoodIDs(plans)
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	foods, err := provider.GetFoodsContext(ctx, ids)
+	for i := range foods {
+		categories[foods[i].FdcID] = foods[i].Category()
+	}
+	return categories, err
+}
+
+// Build additionne les quantités de chaque aliment sur tous les jours de
+// toutes les journées types. categories associe aux identifiants FDC leur
+// catégorie ; les aliments absents, dont les recettes et les aliments
+// personnalisés, sont classés dans « Autres ». Les catégories et les
+// aliments sont triés par ordre alphabétique, la catégorie « Autres » en
+// dernier.
+func Build(plans []PlanDays, categories map[int]string) List {
+	entries := make(map[entryKey]*Entry)
+	var order []entryKey
+	for _, plan := range plans {
+		for _, item := range plan.Items {
+			key := keyOf(item)
+			entry, ok := entries[key]
+			if !ok {
+				entry = &Entry{FoodID: item.FoodID, FoodName: item.FoodName}
+				entries[key] = entry
+				order = append(order, key)
+			}
+			entry.Grams += item.Amount * float64(plan.Days)
+		}
+	}
+
+	groups := make(map[string][]Entry)
+	for _, key := range order {
+		entry := entries[key]
+		name := categories[entry.FoodID]
+		if name == "" {
+			name = UncategorizedLabel
+		}
+		groups[name] = append(groups[name], *entry)
+	}
+
+	list := List{Categories: []Category{}}
+	for name, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].FoodName < group[j].FoodName })
+		list.Categories = append(list.Categories, Category{Name: name, Entries: group})
+	}
+	sort.Slice(list.Categories, func(i, j int) bool {
+		a, b := list.Categories[i].Name, list.Categories[j].Name
+		if a == UncategorizedLabel || b == UncategorizedLabel {
+			return b == UncategorizedLabel && a != UncategorizedLabel
+		}
+		return a < b
+	})
+
+	return list
+}
+
+// Write écrit la liste dans le format demandé
+func (l List) Write(w io.Writer, format Format) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(l)
+	case Markdown:
+		return l.write(w, "# Liste de courses\n", "\n## %s\n\n", "- [ ] %s : %s\n")
+	case Text:
+		return l.write(w, "Liste de courses\n", "\n%s\n", "- %s : %s\n")
+	}
+	return fmt.Errorf("format de liste inconnu: %s", format)
+}
+
+func (l List) write(w io.Writer, title, categoryFormat, entryFormat string) error {
+	var b strings.Builder
+	b.WriteString(title)
+	if len(l.Categories) == 0 {
+		b.WriteString("\nAucun aliment.\n")
+	}
+	for _, category := range l.Categories {
+		fmt.Fprintf(&b, categoryFormat, category.Name)
+		for _, entry := range category.Entries {
+			fmt.Fprintf(&b, entryFormat, entry.FoodName, formatGrams(entry.Grams))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatGrams affiche les quantités importantes en kilogrammes
+func formatGrams(grams float64) string {
+	if grams >= 1000 {
+		return fmt.Sprintf("%.2f kg", grams/1000)
+	}
+	return fmt.Sprintf("%.0f g", grams)
+}
@@ -0,0 +1,176 @@
+package shopping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frachea/macro-tracker/internal/database"
+	"github.com/frachea/macro-tracker/internal/fdc"
+)
+
+var categories = map[int]string{
+	1: "Poultry Products",
+	2: "Cereal Grains and Pasta",
+	3: "Vegetables and Vegetable Products",
+}
+
+func testList() List {
+	training := []database.MealPlanItem{
+		{FoodID: 1, FoodName: "Chicken breast", Amount: 200},
+		{FoodID: 2, FoodName: "Rice, white", Amount: 150},
+		{FoodID: 3, FoodName: "Broccoli", Amount: 100},
+		{FoodName: "Overnight oats", Amount: 250},
+	}
+	rest := []database.MealPlanItem{
+		{FoodID: 1, FoodName: "Chicken breast", Amount: 150},
+		{FoodID: 4, FoodName: "Homemade cake", Amount: 80},
+		{FoodName: "Overnight oats", Amount: 200},
+		{FoodName: "Protein bar", Amount: 60},
+	}
+
+	return Build([]PlanDays{{Items: training, Days: 4}, {Items: rest, Days: 3}}, categories)
+}
+
+func TestBuild(t *testing.T) {
+	list := testList()
+
+	type entry struct {
+		food  string
+		grams float64
+	}
+	expected := []struct {
+		category string
+		entries  []entry
+	}{
+		{"Cereal Grains and Pasta", []entry{{"Rice, white", 600}}},
+		{"Poultry Products", []entry{{"Chicken breast", 1250}}},
+		{"Vegetables and Vegetable Products", []entry{{"Broccoli", 400}}},
+		// Les recettes et aliments personnalisés, sans identifiant FDC, ne
+		// sont regroupés qu'avec les éléments de même nom
+		{UncategorizedLabel, []entry{{"Homemade cake", 240}, {"Overnight oats", 1600}, {"Protein bar", 180}}},
+	}
+
+	if len(list.Categories) != len(expected) {
+		t.Fatalf("%d catégories, attendu %d", len(list.Categories), len(expected))
+	}
+	for i, e := range expected {
+		category := list.Categories[i]
+		if category.Name != e.category {
+			t.Errorf("Catégorie %d = %s, attendu %s", i, category.Name, e.category)
+		}
+		var entries []entry
+		for _, got := range category.Entries {
+			entries = append(entries, entry{got.FoodName, got.Grams})
+		}
+		if !reflect.DeepEqual(entries, e.entries) {
+			t.Errorf("Aliments de %s = %v, attendu %v", category.Name, entries, e.entries)
+		}
+	}
+}
+
+// foodsProvider simule un fournisseur qui connaît la catégorie des aliments
+// de categories et note les appels à GetFoodsContext
+type foodsProvider struct {
+	fdc.Provider
+	calls [][]int
+}
+
+func (p *foodsProvider) GetFoodsContext(ctx context.Context, fdcIDs []int) ([]fdc.Food, error) {
+	p.calls = append(p.calls, fdcIDs)
+	var foods []fdc.Food
+	for _, id := range fdcIDs {
+		if name, ok := categories[id]; ok {
+			foods = append(foods, fdc.Food{FdcID: id, FoodCategory: fdc.FoodCategory{Description: name}})
+		}
+	}
+	return foods, nil
+}
+
+func TestCategories(t *testing.T) {
+	plans := []PlanDays{
+		{Items: []database.MealPlanItem{{FoodID: 1}, {FoodID: 2}, {FoodName: "Overnight oats"}}},
+		{Items: []database.MealPlanItem{{FoodID: 2}, {FoodID: 4}}},
+	}
+	provider := &foodsProvider{}
+
+	got, err := Categories(context.Background(), provider, plans)
+	if err != nil {
+		t.Fatalf("Categories() erreur = %v", err)
+	}
+	if !reflect.DeepEqual(provider.calls, [][]int{{1, 2, 4}}) {
+		t.Errorf("Appels = %v, attendu un seul lot [1 2 4]", provider.calls)
+	}
+	expected := map[int]string{1: categories[1], 2: categories[2]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Categories() = %v, attendu %v", got, expected)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	list := testList()
+
+	tests := []struct {
+		format   Format
+		expected []string
+	}{
+		{format: Text, expected: []string{"Liste de courses\n", "\nPoultry Products\n- Chicken breast : 1.25 kg\n", "- Homemade cake : 240 g\n"}},
+		{format: Markdown, expected: []string{"# Liste de courses\n", "\n## Poultry Products\n\n- [ ] Chicken breast : 1.25 kg\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := list.Write(&buf, tt.format); err != nil {
+				t.Fatalf("Write() erreur = %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(buf.String(), expected) {
+					t.Errorf("La liste ne contient pas %q:\n%s", expected, buf.String())
+				}
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := list.Write(&buf, JSON); err != nil {
+		t.Fatalf("Write() erreur = %v", err)
+	}
+	var decoded List
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON invalide: %v", err)
+	}
+	if len(decoded.Categories) != 4 {
+		t.Errorf("%d catégories décodées, attendu 4", len(decoded.Categories))
+	}
+}
+
+func TestParsePlanSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		planID  int
+		days    int
+		wantErr bool
+	}{
+		{spec: "3", planID: 3, days: 1},
+		{spec: "3:5", planID: 3, days: 5},
+		{spec: "abc", wantErr: true},
+		{spec: "3:0", wantErr: true},
+		{spec: "3:x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			planID, days, err := ParsePlanSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlanSpec(%q) erreur = %v, attendu erreur: %v", tt.spec, err, tt.wantErr)
+			}
+			if planID != tt.planID || days != tt.days {
+				t.Errorf("ParsePlanSpec(%q) = %d, %d, attendu %d, %d", tt.spec, planID, days, tt.planID, tt.days)
+			}
+		})
+	}
+}